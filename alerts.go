@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// alerts maps ticket channel ID -> staff IDs waiting for the user's next message.
+var alerts = struct {
+	sync.Mutex
+	byChannel map[string]map[string]bool
+}{byChannel: map[string]map[string]bool{}}
+
+// cmdAlert subscribes the caller to the user's next reply; "alert cancel"
+// unsubscribes.
+func cmdAlert(c *commandContext) {
+	channelID, staffID := c.m.ChannelID, c.m.Author.ID
+
+	alerts.Lock()
+	defer alerts.Unlock()
+	if len(c.args) > 0 && strings.ToLower(c.args[0]) == "cancel" {
+		delete(alerts.byChannel[channelID], staffID)
+		if len(alerts.byChannel[channelID]) == 0 { delete(alerts.byChannel, channelID) }
+		sendText(c.s, channelID, "🔕 Alert cancelled.")
+		return
+	}
+
+	if alerts.byChannel[channelID] == nil { alerts.byChannel[channelID] = map[string]bool{} }
+	alerts.byChannel[channelID][staffID] = true
+	sendText(c.s, channelID, "🔔 You'll be pinged when the user replies.")
+}
+
+// fireAlerts pings and clears everyone waiting on channelID.
+func fireAlerts(s *discordgo.Session, channelID string) {
+	alerts.Lock()
+	subs := alerts.byChannel[channelID]
+	delete(alerts.byChannel, channelID)
+	alerts.Unlock()
+	if len(subs) == 0 { return }
+
+	var ids, mentions []string
+	for id := range subs {
+		ids = append(ids, id)
+		mentions = append(mentions, "<@"+id+">")
+	}
+	sendMessage(s, channelID, &discordgo.MessageSend{
+		Content:         "🔔 " + strings.Join(mentions, " ") + ", the user replied.",
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: ids},
+	})
+}
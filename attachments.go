@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// attachmentKind is how an attachment can be shown once forwarded.
+type attachmentKind int
+
+const (
+	kindFile attachmentKind = iota
+	kindImage
+	kindVideo
+	kindAudio
+)
+
+// maxUploadSize is Discord's default upload limit for bots.
+const maxUploadSize = 10 << 20
+
+// maxGallery is how many images Discord shows together as a gallery.
+const maxGallery = 4
+
+// attachFiles shows the first image inline and lists every other attachment
+// as a download link, so multi-file messages don't lose anything. Up to
+// maxGallery-1 further images are returned, in order, for chunkEmbeds to show
+// alongside the first as a gallery.
+func attachFiles(embed *discordgo.MessageEmbed, files []*discordgo.MessageAttachment) (gallery []string) {
+	var links []string
+	for _, a := range files {
+		if reason := validateAttachment(a); reason != "" {
+			links = append(links, withheldNote(a, reason))
+			continue
+		}
+		if classifyAttachment(a) == kindImage {
+			if embed.Image == nil {
+				embed.Image = &discordgo.MessageEmbedImage{URL: a.URL}
+				continue
+			}
+			if len(gallery) < maxGallery-1 {
+				gallery = append(gallery, a.URL)
+				continue
+			}
+		}
+		links = append(links, attachmentLink(a))
+	}
+	if len(links) == 0 { return gallery }
+
+	if embed.Description != "" {
+		embed.Description += "\n\n"
+	}
+	embed.Description += strings.Join(links, "\n")
+	return gallery
+}
+
+// classifyAttachment decides what a is from its content type, falling back to
+// the file extension. Only images can go in an embed; the rest become links.
+func classifyAttachment(a *discordgo.MessageAttachment) attachmentKind {
+	switch {
+	case strings.HasPrefix(a.ContentType, "image/"):
+		return kindImage
+	case strings.HasPrefix(a.ContentType, "video/"):
+		return kindVideo
+	case strings.HasPrefix(a.ContentType, "audio/"):
+		return kindAudio
+	}
+	switch strings.ToLower(path.Ext(a.Filename)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return kindImage
+	case ".mp4", ".mov", ".webm", ".mkv", ".avi":
+		return kindVideo
+	case ".mp3", ".ogg", ".wav", ".flac", ".m4a", ".opus":
+		return kindAudio
+	}
+	return kindFile
+}
+
+var (
+	// Attachments bigger than this are withheld; 0 allows any size
+	maxAttachmentSize = envInt("MAX_ATTACHMENT_MB", 25) << 20
+	// Extensions that are never forwarded as links
+	blockedExtensions = extensionSet(envOr("BLOCKED_EXTENSIONS", ".exe,.scr,.bat,.cmd,.com,.msi,.vbs,.js,.jar,.ps1,.apk"))
+)
+
+func extensionSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, ext := range strings.Split(list, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" { continue }
+		if !strings.HasPrefix(ext, ".") { ext = "." + ext }
+		set[ext] = true
+	}
+	return set
+}
+
+// validateAttachment returns why a shouldn't be forwarded, or "" if it's fine.
+func validateAttachment(a *discordgo.MessageAttachment) string {
+	if ext := strings.ToLower(path.Ext(a.Filename)); blockedExtensions[ext] {
+		return ext + " files are blocked"
+	}
+	if maxAttachmentSize > 0 && a.Size > maxAttachmentSize {
+		return "larger than " + formatSize(maxAttachmentSize)
+	}
+	return ""
+}
+
+// withheldNote replaces the link to a rejected attachment.
+func withheldNote(a *discordgo.MessageAttachment, reason string) string {
+	return fmt.Sprintf("⚠️ Attachment `%s` withheld: %s", a.Filename, reason)
+}
+
+// attachmentLink is the download line shown for an attachment.
+func attachmentLink(a *discordgo.MessageAttachment) string {
+	icon := "📎"
+	switch classifyAttachment(a) {
+	case kindVideo:
+		icon = "🎬"
+	case kindAudio:
+		icon = "🎵"
+	}
+	return fmt.Sprintf("%s [%s](%s) (%s)", icon, a.Filename, a.URL, formatSize(a.Size))
+}
+
+// reuploadFiles copies user attachments small enough to upload into
+// channelID, so they outlive the original CDN links. It is a no-op unless the
+// reupload feature is on.
+func reuploadFiles(s *discordgo.Session, channelID string, files []*discordgo.MessageAttachment) {
+	if !features.Reupload { return }
+
+	var uploads []*discordgo.File
+	for _, a := range files {
+		if !canUpload(a) { continue }
+		if f := downloadAttachment(a); f != nil { uploads = append(uploads, f) }
+	}
+
+	// Discord takes at most 10 files per message
+	for len(uploads) > 0 {
+		n := min(len(uploads), 10)
+		if _, err := sendMessage(s, channelID, &discordgo.MessageSend{Files: uploads[:n]}); err != nil {
+			slog.Warn("cannot re-upload attachments", "channel_id", channelID, "err", err)
+			return
+		}
+		uploads = uploads[n:]
+	}
+}
+
+// downloadFiles fetches the attachments that can be uploaded again, keeping
+// their filenames. Those too big, withheld or failing to download are
+// returned in links, to be shown as links instead.
+func downloadFiles(files []*discordgo.MessageAttachment) (uploads []*discordgo.File, links []*discordgo.MessageAttachment) {
+	for _, a := range files {
+		var f *discordgo.File
+		if canUpload(a) { f = downloadAttachment(a) }
+		if f != nil {
+			uploads = append(uploads, f)
+		} else {
+			links = append(links, a)
+		}
+	}
+	return uploads, links
+}
+
+// linkedFiles is the part of files that downloadFiles would leave as links.
+func linkedFiles(files []*discordgo.MessageAttachment) []*discordgo.MessageAttachment {
+	var links []*discordgo.MessageAttachment
+	for _, a := range files {
+		if !canUpload(a) { links = append(links, a) }
+	}
+	return links
+}
+
+func canUpload(a *discordgo.MessageAttachment) bool {
+	return a.Size <= maxUploadSize && validateAttachment(a) == ""
+}
+
+// downloadAttachment reads a into memory, or returns nil if it can't.
+func downloadAttachment(a *discordgo.MessageAttachment) *discordgo.File {
+	resp, err := attachmentClient.Get(a.URL)
+	if err != nil {
+		slog.Warn("cannot download attachment", "url", a.URL, "err", err)
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxUploadSize))
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		slog.Warn("cannot download attachment", "url", a.URL, "status", resp.StatusCode, "err", err)
+		return nil
+	}
+	return &discordgo.File{Name: a.Filename, ContentType: a.ContentType, Reader: bytes.NewReader(data)}
+}
+
+var attachmentClient = &http.Client{Timeout: 30 * time.Second}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
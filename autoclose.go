@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const autoCloseInterval = time.Minute
+
+// startAutoCloser periodically closes idle tickets, runs scheduled closes,
+// flags tickets that have breached the response-time target and promotes
+// queued users into any free slots.
+// All state lives on the ticket documents, so a restart picks up where it
+// left off.
+func startAutoCloser(s *discordgo.Session) {
+	go func() {
+		for range time.Tick(autoCloseInterval) {
+			checkTickets(s)
+			promoteQueued(s)
+		}
+	}()
+}
+
+func checkTickets(s *discordgo.Session) {
+	tickets, err := openTickets()
+	if err != nil {
+		slog.Error("auto-close: cannot list open tickets", "err", err)
+		return
+	}
+
+	now := time.Now()
+	for _, t := range tickets {
+		checkSLA(s, t, now)
+		last, err := lastActivity(t)
+		if err != nil {
+			slog.Error("auto-close: cannot check activity", "user_id", t.UserID, "channel_id", t.ChannelID, "err", err)
+			continue
+		}
+
+		if !t.CloseAt.IsZero() {
+			if last.After(t.CloseScheduledAt) && t.Status == statusResolved {
+				reopenTicket(s, t, nil)
+			} else if last.After(t.CloseScheduledAt) {
+				cancelScheduledClose(t.ChannelID)
+				sendText(s, t.ChannelID, "⏹️ Scheduled close cancelled because a new message arrived.")
+			} else if now.After(t.CloseAt) {
+				closeTicket(s, t.ChannelID, t.UserID, scheduledCloser(s, t.CloseScheduledBy), t.CloseReason, false)
+				continue
+			}
+		}
+
+		if !features.AutoClose { continue }
+		if t.InactivityWarnedAt.IsZero() || last.After(t.InactivityWarnedAt) {
+			if now.Sub(last) >= AutoCloseAfter { warnInactive(s, t) }
+		} else if now.Sub(t.InactivityWarnedAt) >= AutoCloseGrace {
+			closeTicket(s, t.ChannelID, t.UserID, nil, "Closed automatically due to inactivity", false)
+		}
+	}
+}
+
+// lastActivity is the time of the latest user or staff message in the ticket.
+func lastActivity(t Ticket) (time.Time, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var entry ModmailLog
+	err := MsgCol.FindOne(ctx,
+		bson.M{"user_id": t.UserID, "sender": bson.M{"$in": bson.A{"user", "staff"}}},
+		options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && entry.Timestamp.Before(t.CreatedAt)) {
+		return t.CreatedAt, nil
+	}
+	return entry.Timestamp, err
+}
+
+func warnInactive(s *discordgo.Session, t Ticket) {
+	if err := setTicketFields(t.ChannelID, bson.M{"inactivity_warned_at": time.Now()}); err != nil {
+		slog.Error("auto-close: cannot record warning", "user_id", t.UserID, "channel_id", t.ChannelID, "err", err)
+		return
+	}
+	msg := tr("inactive_warning", AutoCloseGrace)
+	if dm, err := openDM(s, t.UserID); err == nil {
+		sendText(s, dm.ID, msg)
+	}
+	sendText(s, t.ChannelID, msg)
+}
+
+// scheduledCloser resolves who scheduled a close, for the close log.
+func scheduledCloser(s *discordgo.Session, userID string) *discordgo.User {
+	if userID == "" { return nil }
+	if u, err := s.User(userID); err == nil { return u }
+	return &discordgo.User{ID: userID, Username: userID}
+}
+
+func scheduleClose(channelID, staffID, reason string, after time.Duration) error {
+	now := time.Now()
+	return setTicketFields(channelID, bson.M{
+		"close_at":           now.Add(after),
+		"close_scheduled_at": now,
+		"close_scheduled_by": staffID,
+		"close_reason":       reason,
+	})
+}
+
+func cancelScheduledClose(channelID string) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketCol.UpdateOne(ctx,
+		bson.M{"channel_id": channelID, "open": true},
+		bson.M{"$unset": bson.M{"close_at": "", "close_scheduled_at": "", "close_scheduled_by": "", "close_reason": ""}})
+	if err != nil {
+		slog.Error("cannot cancel scheduled close", "channel_id", channelID, "err", err)
+	}
+}
+
+// parseDuration is time.ParseDuration plus a "d" suffix for days.
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil { return 0, fmt.Errorf("invalid duration %q", s) }
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// AutoResponder answers a user's first message when it matches Pattern: a
+// keyword, or a regex when written as /expression/. Matching ignores case.
+type AutoResponder struct {
+	Pattern  string `bson:"_id"`
+	Response string `bson:"response"`
+	// Tag given to the ticket that opens after the reply
+	Tag string `bson:"tag,omitempty"`
+	// Answer without opening a ticket at all
+	SuppressTicket bool `bson:"suppress_ticket"`
+
+	re *regexp.Regexp
+}
+
+func (a *AutoResponder) compile() error {
+	expr := `\b` + regexp.QuoteMeta(a.Pattern) + `\b`
+	if len(a.Pattern) > 2 && strings.HasPrefix(a.Pattern, "/") && strings.HasSuffix(a.Pattern, "/") {
+		expr = a.Pattern[1 : len(a.Pattern)-1]
+	}
+	re, err := regexp.Compile("(?i)" + expr)
+	a.re = re
+	return err
+}
+
+// autoResponders mirrors the autoresponders collection; autoTags holds the
+// tag a matched rule wants on the ticket the user is about to open.
+var autoResponders = struct {
+	sync.RWMutex
+	rules []*AutoResponder
+	tags  map[string]string
+}{tags: map[string]string{}}
+
+func loadAutoResponders() error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := AutoResponderCol.Find(ctx, bson.M{})
+	if err != nil { return err }
+	var all []*AutoResponder
+	if err = cur.All(ctx, &all); err != nil { return err }
+
+	var rules []*AutoResponder
+	for _, a := range all {
+		if err := a.compile(); err != nil {
+			slog.Warn("skipping invalid autoresponder", "pattern", a.Pattern, "err", err)
+			continue
+		}
+		rules = append(rules, a)
+	}
+	autoResponders.Lock()
+	autoResponders.rules = rules
+	autoResponders.Unlock()
+	return nil
+}
+
+// autoRespond answers m, a user's first message, if a rule matches. It
+// reports true when the rule suppresses the ticket, so nothing else should
+// happen with the message.
+func autoRespond(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	autoResponders.RLock()
+	var rule *AutoResponder
+	for _, a := range autoResponders.rules {
+		if a.re.MatchString(m.Content) {
+			rule = a
+			break
+		}
+	}
+	autoResponders.RUnlock()
+	if rule == nil { return false }
+
+	if _, err := sendDM(s, m.ChannelID, staffEmbeds(rule.Response, nil, nil)); err != nil {
+		slog.Warn("cannot send auto-reply", "user_id", m.Author.ID, "pattern", rule.Pattern, "err", err)
+		return false
+	}
+	slog.Info("auto-replied", "user_id", m.Author.ID, "pattern", rule.Pattern, "suppress_ticket", rule.SuppressTicket)
+	if rule.SuppressTicket {
+		logToDB(m.Author.ID, m.Content, "user", len(m.Attachments) > 0)
+		logToDB(m.Author.ID, "Auto-reply ("+rule.Pattern+"): "+rule.Response, "system", false)
+		return true
+	}
+	if rule.Tag != "" {
+		autoResponders.Lock()
+		autoResponders.tags[m.Author.ID] = rule.Tag
+		autoResponders.Unlock()
+	}
+	return false
+}
+
+// applyAutoTag tags a newly opened ticket with whatever the user's auto-reply
+// asked for, if anything.
+func applyAutoTag(s *discordgo.Session, userID string, ch *discordgo.Channel) {
+	autoResponders.Lock()
+	tag, ok := autoResponders.tags[userID]
+	delete(autoResponders.tags, userID)
+	autoResponders.Unlock()
+	if !ok { return }
+
+	tags, err := updateTags(ch.ID, tag, true)
+	if err != nil {
+		slog.Warn("cannot apply auto-reply tag", "channel_id", ch.ID, "tag", tag, "err", err)
+		return
+	}
+	if !ch.IsThread() { s.ChannelEdit(ch.ID, &discordgo.ChannelEdit{Topic: ticketTopic(userID, tags)}) }
+}
+
+// cmdAutoReply manages the rules: "autoreply add [-s] [tag:<tag>] <keyword or
+// /regex/> <response>", "autoreply remove <pattern>", "autoreply list" and
+// "autoreply reload". -s answers without opening a ticket.
+func cmdAutoReply(c *commandContext) {
+	usage := "Usage: `" + Prefix + "autoreply add [-s] [tag:<tag>] <keyword or /regex/> <response>`, `" +
+		Prefix + "autoreply remove <pattern>`, `" + Prefix + "autoreply list` or `" + Prefix + "autoreply reload`"
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	var err error
+	switch strings.ToLower(c.args[0]) {
+	case "add":
+		rule, skip := AutoResponder{}, 1
+		for _, arg := range c.args[1:] {
+			if arg == "-s" {
+				rule.SuppressTicket = true
+			} else if tag, ok := strings.CutPrefix(strings.ToLower(arg), "tag:"); ok {
+				rule.Tag = tag
+			} else {
+				break
+			}
+			skip++
+		}
+		if len(c.args) < skip+2 || (rule.Tag != "" && !tagName.MatchString(rule.Tag)) {
+			sendText(c.s, c.m.ChannelID, usage)
+			return
+		}
+		rule.Pattern, rule.Response = c.args[skip], skipFields(c.rest, skip+1)
+		if cerr := rule.compile(); cerr != nil {
+			sendText(c.s, c.m.ChannelID, "❌ Invalid expression: "+cerr.Error())
+			return
+		}
+		if _, err = AutoResponderCol.InsertOne(ctx, rule); mongo.IsDuplicateKeyError(err) {
+			sendText(c.s, c.m.ChannelID, "That pattern already has an auto-reply.")
+			return
+		}
+	case "remove":
+		var res *mongo.DeleteResult
+		if res, err = AutoResponderCol.DeleteOne(ctx, bson.M{"_id": skipFields(c.rest, 1)}); err == nil && res.DeletedCount == 0 {
+			sendText(c.s, c.m.ChannelID, "❌ No such auto-reply.")
+			return
+		}
+	case "list":
+		autoResponders.RLock()
+		lines := []string{fmt.Sprintf("💬 %d auto-replies", len(autoResponders.rules))}
+		for _, a := range autoResponders.rules {
+			line := "`" + strings.ReplaceAll(a.Pattern, "`", "'") + "` → " + truncate(a.Response, 80)
+			if a.Tag != "" { line += " · tag `" + a.Tag + "`" }
+			if a.SuppressTicket { line += " · no ticket" }
+			lines = append(lines, line)
+		}
+		autoResponders.RUnlock()
+		sendText(c.s, c.m.ChannelID, strings.Join(lines, "\n"))
+		return
+	case "reload":
+	default:
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	if err == nil { err = loadAutoResponders() }
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot update autoresponders", "command", c.args[0], "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update the auto-replies.")
+		return
+	}
+	autoResponders.RLock()
+	n := len(autoResponders.rules)
+	autoResponders.RUnlock()
+	slog.Info("autoresponders changed", "by", c.m.Author.ID, "command", c.args[0], "active", n)
+	sendText(c.s, c.m.ChannelID, fmt.Sprintf("✅ %d auto-replies active.", n))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// awayCooldown keeps a chatty user from getting the away notice on every DM.
+const awayCooldown = time.Hour
+
+var awayNotified = struct {
+	sync.Mutex
+	byUser map[string]time.Time
+}{byUser: map[string]time.Time{}}
+
+// cmdAway turns away mode on with "away <message>"; "here" turns it off.
+func cmdAway(c *commandContext) {
+	msg := c.rest
+	if c.name == "here" {
+		msg = ""
+	} else if msg == "" {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"away <message>`")
+		return
+	}
+	if err := setSetting("away_message", msg); err != nil {
+		slog.Error("cannot update away message", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update away mode.")
+		return
+	}
+
+	awayNotified.Lock()
+	clear(awayNotified.byUser)
+	awayNotified.Unlock()
+	if msg == "" {
+		sendText(c.s, c.m.ChannelID, "👋 Away mode off.")
+	} else {
+		sendText(c.s, c.m.ChannelID, "🌙 Away mode on. Users will be told: "+msg)
+	}
+}
+
+// awayMessage is the auto-reply in effect right now, if any: the !away message,
+// or AUTO_AWAY_MESSAGE outside office hours.
+func awayMessage(now time.Time) string {
+	if msg := settings().AwayMessage; msg != "" { return msg }
+	if AutoAwayMessage != "" && !inOfficeHours(now) { return AutoAwayMessage }
+	return ""
+}
+
+// sendAwayNotice tells the user staff are away, at most once per cooldown.
+func sendAwayNotice(s *discordgo.Session, m *discordgo.MessageCreate) {
+	now := time.Now()
+	msg := awayMessage(now)
+	if msg == "" { return }
+
+	awayNotified.Lock()
+	last, seen := awayNotified.byUser[m.Author.ID]
+	if seen && now.Sub(last) < awayCooldown {
+		awayNotified.Unlock()
+		return
+	}
+	awayNotified.byUser[m.Author.ID] = now
+	awayNotified.Unlock()
+
+	sendEmbed(s, m.ChannelID, &discordgo.MessageEmbed{
+		Title: tr("away_title"),
+		Description: msg,
+		Color: settings().StaffColor,
+	})
+}
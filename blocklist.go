@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type BlockedUser struct {
+	UserID    string    `bson:"user_id"`
+	BlockedBy string    `bson:"blocked_by"`
+	BlockedAt time.Time `bson:"blocked_at"`
+	// Unblocked users are kept so !history can tell they were once blocked
+	UnblockedAt time.Time `bson:"unblocked_at,omitempty"`
+}
+
+// blocklist mirrors the blocked_users collection so incoming DMs don't need a
+// database round trip.
+var blocklist = struct {
+	sync.RWMutex
+	ids map[string]bool
+}{ids: map[string]bool{}}
+
+func loadBlocklist() error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := BlockCol.Find(ctx, bson.M{"unblocked_at": bson.M{"$exists": false}})
+	if err != nil { return err }
+	var users []BlockedUser
+	if err = cur.All(ctx, &users); err != nil { return err }
+
+	ids := make(map[string]bool, len(users))
+	for _, u := range users {
+		ids[u.UserID] = true
+	}
+	blocklist.Lock()
+	blocklist.ids = ids
+	blocklist.Unlock()
+	return nil
+}
+
+func blocklistSize() int {
+	blocklist.RLock()
+	defer blocklist.RUnlock()
+	return len(blocklist.ids)
+}
+
+func isBlocked(userID string) bool {
+	blocklist.RLock()
+	defer blocklist.RUnlock()
+	return blocklist.ids[userID]
+}
+
+func blockUser(userID, staffID string) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := BlockCol.UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": BlockedUser{UserID: userID, BlockedBy: staffID, BlockedAt: time.Now()}, "$unset": bson.M{"unblocked_at": ""}},
+		options.Update().SetUpsert(true))
+	if err != nil { return err }
+	refreshBlocklist()
+	return nil
+}
+
+func unblockUser(userID string) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := BlockCol.UpdateOne(ctx,
+		bson.M{"user_id": userID, "unblocked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"unblocked_at": time.Now()}})
+	if err != nil { return err }
+	refreshBlocklist()
+	return nil
+}
+
+func refreshBlocklist() {
+	if err := loadBlocklist(); err != nil {
+		slog.Error("cannot reload blocklist", "err", err)
+	}
+}
+
+// blockRecord returns the user's block, current or lifted, or nil if they
+// have never been blocked.
+func blockRecord(userID string) (*BlockedUser, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var b BlockedUser
+	err := BlockCol.FindOne(ctx, bson.M{"user_id": userID}).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) { return nil, nil }
+	if err != nil { return nil, err }
+	return &b, nil
+}
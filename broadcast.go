@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// broadcastInterval spaces out broadcast DMs, well under Discord's limits and
+// its spam detection.
+const broadcastInterval = 500 * time.Millisecond
+
+// cmdBroadcast DMs "broadcast <message>" to every user with an open ticket.
+// It is admin only and reports how many users it reached when done.
+func cmdBroadcast(c *commandContext) {
+	if c.rest == "" {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"broadcast <message>`")
+		return
+	}
+	tickets, err := openTickets()
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot list open tickets", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not list open tickets.")
+		return
+	}
+	sendText(c.s, c.m.ChannelID, fmt.Sprintf("📢 Broadcasting to %d users…", len(tickets)))
+	slog.Info("broadcast started", "by", c.m.Author.ID, "users", len(tickets))
+	logAction(c.s, modEvent{Action: "broadcast", Actor: c.m.Author, Detail: c.rest})
+
+	go func() {
+		embed := &discordgo.MessageEmbed{
+			Title: tr("broadcast_title"),
+			Description: truncate(c.rest, maxEmbedDesc),
+			Color: settings().StaffColor,
+			Timestamp: time.Now().Format(time.RFC3339),
+		}
+		var sent, closed, failed int
+		for i, t := range tickets {
+			if i > 0 { time.Sleep(broadcastInterval) }
+			dm, err := openDM(c.s, t.UserID)
+			if err == nil { _, err = sendDM(c.s, dm.ID, []*discordgo.MessageEmbed{embed}) }
+			switch {
+			case err == nil:
+				sent++
+				logToDB(t.UserID, "Broadcast: "+c.rest, "system", false)
+			case isCannotDM(err):
+				closed++
+			default:
+				failed++
+				slog.Warn("cannot deliver broadcast", "user_id", t.UserID, "err", err)
+			}
+		}
+		slog.Info("broadcast finished", "sent", sent, "dms_closed", closed, "failed", failed)
+		sendText(c.s, c.m.ChannelID, fmt.Sprintf("📢 Broadcast sent to %d users; %d have DMs closed, %d failed.", sent, closed, failed))
+	}()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// queuedUser is someone waiting for a free slot under MAX_OPEN_TICKETS. Only
+// message IDs are kept; the messages are fetched back from the DM channel on
+// promotion, so nothing is lost across restarts.
+type queuedUser struct {
+	UserID      string    `bson:"_id"`
+	DMChannelID string    `bson:"dm_channel_id"`
+	MessageIDs  []string  `bson:"message_ids"`
+	QueuedAt    time.Time `bson:"queued_at"`
+}
+
+// promoting keeps concurrent closes from promoting past the limit.
+var promoting sync.Mutex
+
+// queueForCapacity buffers m instead of opening a ticket when MAX_OPEN_TICKETS
+// tickets are already open, or when its author is already waiting. Callers
+// hold the user's lock.
+func queueForCapacity(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if MaxOpenTickets == 0 { return false }
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	res, err := QueueCol.UpdateOne(ctx, bson.M{"_id": m.Author.ID}, bson.M{"$push": bson.M{"message_ids": m.ID}})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot check ticket queue", "user_id", m.Author.ID, "err", err)
+		return false
+	}
+	if res.MatchedCount > 0 {
+		react(s, m.ChannelID, m.ID, "⏳")
+		return true
+	}
+
+	open, err := TicketCol.CountDocuments(ctx, bson.M{"open": true})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot count open tickets", "err", err)
+		return false
+	}
+	if open < int64(MaxOpenTickets) { return false }
+
+	q := queuedUser{UserID: m.Author.ID, DMChannelID: m.ChannelID, MessageIDs: []string{m.ID}, QueuedAt: time.Now()}
+	if _, err := QueueCol.InsertOne(ctx, q); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot queue user", "user_id", m.Author.ID, "err", err)
+		return false
+	}
+	position, err := QueueCol.CountDocuments(ctx, bson.M{"queued_at": bson.M{"$lte": q.QueuedAt}})
+	if err != nil { position = 0 }
+	slog.Info("ticket queued at capacity", "user_id", m.Author.ID, "open", open, "position", position)
+
+	react(s, m.ChannelID, m.ID, "⏳")
+	msg := tr("capacity")
+	if position > 0 { msg = tr("capacity_position", position) }
+	sendText(s, m.ChannelID, msg)
+	if LogChannelID != "" {
+		sendText(s, LogChannelID, fmt.Sprintf("🚦 %d tickets are open, the limit. <@%s> is queued at position %d.", open, m.Author.ID, position))
+	}
+	return true
+}
+
+// promoteQueued opens tickets for queued users, oldest first, while there is
+// room under MAX_OPEN_TICKETS. It stops at the first user it can't promote,
+// who keeps their place for the next run.
+func promoteQueued(s *discordgo.Session) {
+	if MaxOpenTickets == 0 { return }
+	promoting.Lock()
+	defer promoting.Unlock()
+	for {
+		ctx, cancel := dbCtx()
+		open, err := TicketCol.CountDocuments(ctx, bson.M{"open": true})
+		if err != nil || open >= int64(MaxOpenTickets) {
+			cancel()
+			return
+		}
+		var q queuedUser
+		err = QueueCol.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "queued_at", Value: 1}})).Decode(&q)
+		cancel()
+		if errors.Is(err, mongo.ErrNoDocuments) { return }
+		if err != nil {
+			dbErrors.Inc()
+			slog.Error("cannot read next queued user", "err", err)
+			return
+		}
+		if !promoteUser(s, q.UserID) { return }
+	}
+}
+
+// promoteUser opens the queued user's ticket, forwards what they sent while
+// waiting and only then takes them off the queue. It reports whether the user
+// left the queue; on failure they keep their place.
+func promoteUser(s *discordgo.Session, userID string) bool {
+	unlock := lockUser(userID)
+	defer unlock()
+
+	// Re-read under the lock, so messages queued meanwhile are included
+	ctx, cancel := dbCtx()
+	var q queuedUser
+	err := QueueCol.FindOne(ctx, bson.M{"_id": userID}).Decode(&q)
+	cancel()
+	if errors.Is(err, mongo.ErrNoDocuments) { return true }
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot read queued user", "user_id", userID, "err", err)
+		return false
+	}
+
+	var messages []*discordgo.MessageCreate
+	for _, id := range q.MessageIDs {
+		msg, err := s.ChannelMessage(q.DMChannelID, id)
+		if isUnknownMessage(err) {
+			slog.Warn("queued message was deleted", "user_id", userID, "message_id", id)
+			continue
+		}
+		if err != nil {
+			slog.Warn("cannot fetch queued message", "user_id", userID, "message_id", id, "err", err)
+			return false
+		}
+		messages = append(messages, &discordgo.MessageCreate{Message: msg})
+	}
+	if len(messages) == 0 {
+		// Nothing left to forward; tell the user rather than drop them quietly
+		sendText(s, q.DMChannelID, tr("queue_lost"))
+		return dequeue(userID)
+	}
+
+	ch := ticketChannel(s, userID)
+	if ch == nil { ch = openTicket(s, messages[0].Author, q.DMChannelID, resolveTarget(userID), nil) }
+	if ch == nil { return false }
+	slog.Info("promoted queued ticket", "user_id", userID, "channel_id", ch.ID, "waited", time.Since(q.QueuedAt))
+	for _, m := range messages {
+		forwardUserMessage(s, m, ch)
+	}
+	return dequeue(userID)
+}
+
+// dequeue takes a user off the capacity queue.
+func dequeue(userID string) bool {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	if _, err := QueueCol.DeleteOne(ctx, bson.M{"_id": userID}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot remove user from ticket queue", "user_id", userID, "err", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a new user has to pick a category before the default is used.
+const categoryTimeout = 2 * time.Minute
+
+// pendingTicket holds a first-contact user's DMs while they pick a category.
+type pendingTicket struct {
+	messages []*discordgo.MessageCreate
+	promptID string
+	timer    *time.Timer
+}
+
+var pending = struct {
+	sync.Mutex
+	byUser map[string]*pendingTicket
+}{byUser: map[string]*pendingTicket{}}
+
+// categoryTargets are the targets offered on first contact: those with a label.
+func categoryTargets() []Target {
+	var targets []Target
+	for _, t := range config.Targets {
+		if t.Label != "" { targets = append(targets, t) }
+	}
+	return targets
+}
+
+// queuePending holds m back if its author is still choosing a category.
+func queuePending(m *discordgo.MessageCreate) bool {
+	pending.Lock()
+	defer pending.Unlock()
+	p, ok := pending.byUser[m.Author.ID]
+	if ok { p.messages = append(p.messages, m) }
+	return ok
+}
+
+// promptCategory asks a first-time user which category their ticket is about.
+// It returns false when there is nothing to choose from, or the user is
+// routed explicitly, and the ticket should be opened straight away.
+func promptCategory(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	targets := categoryTargets()
+	if len(targets) == 0 { return false }
+	if _, routed := config.Overrides[m.Author.ID]; routed { return false }
+
+	var rows []discordgo.MessageComponent
+	var row discordgo.ActionsRow
+	for _, t := range targets {
+		row.Components = append(row.Components, discordgo.Button{
+			Label: t.Label, Style: discordgo.PrimaryButton, CustomID: "category:" + t.Name,
+		})
+		if len(row.Components) == 5 {
+			rows = append(rows, row)
+			row = discordgo.ActionsRow{}
+		}
+	}
+	if len(row.Components) > 0 { rows = append(rows, row) }
+
+	prompt, err := sendMessage(s, m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: tr("category_title"),
+			Description: tr("category_prompt"),
+			Color: settings().StaffColor,
+		}},
+		Components: rows,
+	})
+	if err != nil {
+		slog.Warn("cannot send category prompt", "user_id", m.Author.ID, "err", err)
+		return false
+	}
+
+	userID := m.Author.ID
+	pending.Lock()
+	pending.byUser[userID] = &pendingTicket{
+		messages: []*discordgo.MessageCreate{m},
+		promptID: prompt.ID,
+		timer: time.AfterFunc(categoryTimeout, func() {
+			finishPending(s, userID, config.Targets[0], true)
+		}),
+	}
+	pending.Unlock()
+	return true
+}
+
+// handleCategoryPick opens the ticket in the category the user clicked.
+func handleCategoryPick(s *discordgo.Session, i *discordgo.InteractionCreate, name string) {
+	target, ok := targetByName(name)
+	if !ok || i.User == nil {
+		respondEphemeral(s, i, tr("category_expired"))
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	finishPending(s, i.User.ID, target, false)
+}
+
+// finishPending opens the ticket for a user who was choosing a category and
+// forwards everything they sent in the meantime.
+func finishPending(s *discordgo.Session, userID string, target Target, timedOut bool) {
+	// Hold the user's lock before the entry goes, or a DM arriving in
+	// between would find neither a ticket nor a prompt and ask again
+	unlock := lockUser(userID)
+	defer unlock()
+	pending.Lock()
+	p := pending.byUser[userID]
+	delete(pending.byUser, userID)
+	pending.Unlock()
+	if p == nil { return }
+	p.timer.Stop()
+
+	first := p.messages[0]
+	status := tr("category_chosen", target.Label)
+	if timedOut { status = tr("category_timeout") }
+	empty := []discordgo.MessageComponent{}
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID: p.promptID, Channel: first.ChannelID, Content: &status,
+		Embeds: &[]*discordgo.MessageEmbed{}, Components: &empty,
+	})
+
+	ch := ticketChannel(s, userID)
+	if ch == nil { ch = openTicket(s, first.Author, first.ChannelID, target, nil) }
+	if ch == nil { return }
+	for _, m := range p.messages {
+		forwardUserMessage(s, m, ch)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelTTL is how long a guild's channel list is trusted before refetching.
+const channelTTL = 60 * time.Second
+
+type cachedChannels struct {
+	channels  []*discordgo.Channel
+	fetchedAt time.Time
+}
+
+// channelCache holds each staff guild's channel list so the topic fallback in
+// ticketChannel doesn't hit the API on every DM.
+var channelCache = struct {
+	sync.RWMutex
+	byGuild      map[string]cachedChannels
+	hits, misses atomic.Int64
+}{byGuild: map[string]cachedChannels{}}
+
+// guildChannels returns guildID's channels, from the cache while it's fresh.
+func guildChannels(s *discordgo.Session, guildID string) []*discordgo.Channel {
+	channelCache.RLock()
+	c, ok := channelCache.byGuild[guildID]
+	channelCache.RUnlock()
+	if ok && time.Since(c.fetchedAt) < channelTTL {
+		channelCache.hits.Add(1)
+		return c.channels
+	}
+	channelCache.misses.Add(1)
+	return refreshChannels(s, guildID)
+}
+
+// refreshChannels refetches guildID's channel list and caches it. On failure
+// the stale list, if any, is kept and returned.
+func refreshChannels(s *discordgo.Session, guildID string) []*discordgo.Channel {
+	channels, err := s.GuildChannels(guildID)
+	channelCache.Lock()
+	defer channelCache.Unlock()
+	if err != nil {
+		slog.Error("cannot list guild channels", "guild_id", guildID, "err", err)
+		return channelCache.byGuild[guildID].channels
+	}
+	channelCache.byGuild[guildID] = cachedChannels{channels: channels, fetchedAt: time.Now()}
+	return channels
+}
+
+func invalidateChannels(guildID string) {
+	channelCache.Lock()
+	defer channelCache.Unlock()
+	delete(channelCache.byGuild, guildID)
+}
+
+func channelCreate(s *discordgo.Session, c *discordgo.ChannelCreate) { invalidateChannels(c.GuildID) }
+func channelUpdate(s *discordgo.Session, c *discordgo.ChannelUpdate) { invalidateChannels(c.GuildID) }
+func channelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) { invalidateChannels(c.GuildID) }
+
+func channelCacheStats() (hits, misses int64) {
+	return channelCache.hits.Load(), channelCache.misses.Load()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long staff have to confirm a !close.
+const closeConfirmTimeout = 30 * time.Second
+
+// pendingClose is a !close waiting for staff to press confirm.
+type pendingClose struct {
+	userID   string
+	reason   string
+	silent   bool
+	promptID string
+	timer    *time.Timer
+}
+
+var closeConfirms = struct {
+	sync.Mutex
+	byChannel map[string]*pendingClose
+}{byChannel: map[string]*pendingClose{}}
+
+// askCloseConfirm posts the confirm/cancel buttons for closing channelID,
+// replacing any earlier prompt in the same channel.
+func askCloseConfirm(s *discordgo.Session, channelID, userID, reason string, silent bool) {
+	description := "This can't be undone. Use `" + Prefix + "close force` to skip this step."
+	if silent { description = "The user won't be told. " + description }
+	prompt, err := sendMessage(s, channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: "🔒 Close this ticket?",
+			Description: description,
+			Color: 0xe74c3c,
+		}},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Close", Emoji: &discordgo.ComponentEmoji{Name: "✅"}, Style: discordgo.DangerButton, CustomID: "close:yes"},
+			discordgo.Button{Label: "Cancel", Emoji: &discordgo.ComponentEmoji{Name: "❌"}, Style: discordgo.SecondaryButton, CustomID: "close:no"},
+		}}},
+	})
+	if err != nil { return }
+
+	closeConfirms.Lock()
+	if old := closeConfirms.byChannel[channelID]; old != nil { old.timer.Stop() }
+	closeConfirms.byChannel[channelID] = &pendingClose{
+		userID: userID, reason: reason, silent: silent, promptID: prompt.ID,
+		timer: time.AfterFunc(closeConfirmTimeout, func() {
+			if p := takeCloseConfirm(channelID, prompt.ID); p != nil {
+				resolveClosePrompt(s, channelID, p.promptID, "⌛ Close request timed out.")
+			}
+		}),
+	}
+	closeConfirms.Unlock()
+}
+
+// takeCloseConfirm removes and returns the pending close for channelID if it
+// belongs to promptID.
+func takeCloseConfirm(channelID, promptID string) *pendingClose {
+	closeConfirms.Lock()
+	defer closeConfirms.Unlock()
+	p := closeConfirms.byChannel[channelID]
+	if p == nil || p.promptID != promptID { return nil }
+	delete(closeConfirms.byChannel, channelID)
+	p.timer.Stop()
+	return p
+}
+
+// handleCloseConfirm acts on the confirm/cancel buttons; arg is "yes" or "no".
+func handleCloseConfirm(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !hasPermission(s, i.GuildID, i.Member.User.ID, permStaff) {
+		respondEphemeral(s, i, "⛔ You don't have permission to close this ticket.")
+		return
+	}
+	p := takeCloseConfirm(i.ChannelID, i.Message.ID)
+	if p == nil {
+		respondEphemeral(s, i, "This close request has expired.")
+		return
+	}
+
+	status := "❎ Close cancelled by " + i.Member.User.Mention() + "."
+	if arg == "yes" { status = "🔒 Closing ticket." }
+	empty := []discordgo.MessageComponent{}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: status, Embeds: []*discordgo.MessageEmbed{}, Components: empty},
+	})
+	if arg == "yes" { closeTicket(s, i.ChannelID, p.userID, i.Member.User, p.reason, p.silent) }
+}
+
+func resolveClosePrompt(s *discordgo.Session, channelID, promptID, status string) {
+	empty := []discordgo.MessageComponent{}
+	s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID: promptID, Channel: channelID, Content: &status,
+		Embeds: &[]*discordgo.MessageEmbed{}, Components: &empty,
+	})
+}
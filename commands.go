@@ -0,0 +1,180 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var slashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "close",
+		Description: "Close this ticket",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "reason", Description: "Reason shown to the user"},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "silent", Description: "Don't tell the user the ticket was closed"},
+		},
+	},
+	{
+		Name:        "reply",
+		Description: "Reply to the ticket user",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Message to send", Required: true},
+		},
+	},
+	{
+		Name:        "areply",
+		Description: "Reply to the ticket user anonymously",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "Message to send", Required: true},
+		},
+	},
+}
+
+var claimCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "claim",
+		Description: "Take ownership of this ticket",
+	},
+	{
+		Name:        "unclaim",
+		Description: "Release your claim on this ticket",
+	},
+}
+
+// registerCommands creates the slash commands in each staff guild. Guild-scoped
+// commands show up immediately, unlike global ones.
+func registerCommands(s *discordgo.Session) {
+	cmds := slashCommands
+	if features.Claiming { cmds = append(cmds, claimCommands...) }
+
+	for _, guildID := range staffGuilds() {
+		for _, cmd := range cmds {
+			if _, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd); err != nil {
+				slog.Error("cannot create slash command", "command", cmd.Name, "guild_id", guildID, "err", err)
+			}
+		}
+	}
+}
+
+func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		handleSlashCommand(s, i)
+	case discordgo.InteractionMessageComponent:
+		handleComponent(s, i)
+	}
+}
+
+// componentHandlers route button presses by the custom ID prefix before the
+// first ":"; the rest of the ID is passed along as arg.
+var componentHandlers map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate, arg string)
+
+func init() {
+	componentHandlers = map[string]func(*discordgo.Session, *discordgo.InteractionCreate, string){
+		"logs":      handleLogsPage,
+		"category":  handleCategoryPick,
+		"close":     handleCloseConfirm,
+		"search":    handleSearchPage,
+		"userclose": handleUserClose,
+		"resolve":   handleResolve,
+		"tickets":   handleTicketsPage,
+	}
+}
+
+func handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name, arg, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	if h, ok := componentHandlers[name]; ok { h(s, i, arg) }
+}
+
+func handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := ticketUser(s, i.ChannelID)
+	if userID == "" {
+		respondEphemeral(s, i, "This command only works inside a ticket channel.")
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	if i.Member == nil || !hasPermission(s, i.GuildID, i.Member.User.ID, commandLevels[data.Name]) {
+		respondEphemeral(s, i, "⛔ You don't have permission to use that command.")
+		return
+	}
+	switch data.Name {
+	case "close":
+		respondEphemeral(s, i, "🔒 Closing ticket.")
+		reason, silent := "", false
+		for _, o := range data.Options {
+			switch o.Name {
+			case "reason":
+				reason = o.StringValue()
+			case "silent":
+				silent = o.BoolValue()
+			}
+		}
+		closeTicket(s, i.ChannelID, userID, i.Member.User, reason, silent)
+	case "claim":
+		claimer, err := claimTicket(i.ChannelID, i.Member.User.ID)
+		switch {
+		case err != nil:
+			slog.Error("cannot claim ticket", "channel_id", i.ChannelID, "err", err)
+			respondEphemeral(s, i, "❌ Could not claim this ticket.")
+		case claimer != i.Member.User.ID:
+			respondEphemeral(s, i, "This ticket is claimed by <@"+claimer+">.")
+		default:
+			respondEphemeral(s, i, "✅ Ticket claimed.")
+			sendText(s, i.ChannelID, "🙋 Ticket claimed by "+i.Member.User.Mention())
+			logAction(s, modEvent{Action: "claim", Actor: i.Member.User, UserID: userID, ChannelID: i.ChannelID})
+			refreshUserStatus(s, i.ChannelID)
+		}
+	case "unclaim":
+		if err := unclaimTicket(i.ChannelID, i.Member.User.ID); err != nil {
+			respondEphemeral(s, i, "❌ "+err.Error())
+			return
+		}
+		respondEphemeral(s, i, "✅ Claim released.")
+		sendText(s, i.ChannelID, "👋 "+i.Member.User.Mention()+" released this ticket.")
+		logAction(s, modEvent{Action: "unclaim", Actor: i.Member.User, UserID: userID, ChannelID: i.ChannelID})
+		refreshUserStatus(s, i.ChannelID)
+	case "reply", "areply":
+		t, err := findTicketByChannel(i.ChannelID)
+		if err != nil {
+			dbErrors.Inc()
+			slog.Error("cannot look up ticket", "channel_id", i.ChannelID, "err", err)
+		}
+		if t != nil && t.NoRelay {
+			respondEphemeral(s, i, "🔇 Relaying is paused in this ticket. Use `"+Prefix+"relay on` first.")
+			return
+		}
+		if t != nil && t.ClaimedBy != "" && t.ClaimedBy != i.Member.User.ID {
+			respondEphemeral(s, i, "This ticket is claimed by <@"+t.ClaimedBy+">.")
+			return
+		}
+		content := data.Options[0].StringValue()
+		var author *discordgo.User
+		if data.Name == "reply" { author = i.Member.User }
+
+		// Acknowledge now and relay on the user's worker, behind anything
+		// already queued for them
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+		})
+		enqueueForward(userID, func() {
+			status := "✅ Reply sent."
+			if _, err := relayToUser(s, userID, "", content, nil, author); err != nil {
+				status = deliveryFailure(i.ChannelID, userID, err)
+			} else {
+				recordStaffReply(s, i.ChannelID, content, nil, author)
+			}
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &status})
+		})
+	}
+}
+
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
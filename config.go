@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Target is a staff guild and category that tickets can be opened in.
+type Target struct {
+	Name       string `json:"name"`
+	GuildID    string `json:"guild_id"`
+	CategoryID string `json:"category_id"`
+	// Targets with a label are offered as buttons when a user first DMs
+	Label string `json:"label"`
+}
+
+// Config holds the ticket routing rules. The first target is the default and
+// always comes from STAFF_GUILD_ID / CATEGORY_ID; ROUTING_FILE can add more
+// targets and per-user overrides.
+type Config struct {
+	Targets []Target `json:"targets"`
+	// User ID -> target name
+	Overrides map[string]string `json:"overrides"`
+}
+
+var config Config
+
+func loadConfig() error {
+	config = Config{Targets: []Target{{Name: "default", GuildID: GuildID, CategoryID: CategoryID}}}
+
+	path := os.Getenv("ROUTING_FILE")
+	if path == "" { return nil }
+	data, err := os.ReadFile(path)
+	if err != nil { return err }
+
+	var file Config
+	if err = json.Unmarshal(data, &file); err != nil { return fmt.Errorf("%s: %w", path, err) }
+	for _, t := range file.Targets {
+		if t.Name == "" || t.GuildID == "" || t.CategoryID == "" {
+			return fmt.Errorf("%s: target %q needs name, guild_id and category_id", path, t.Name)
+		}
+	}
+	config.Targets = append(config.Targets, file.Targets...)
+	config.Overrides = file.Overrides
+	for user, name := range config.Overrides {
+		if _, ok := targetByName(name); !ok {
+			return fmt.Errorf("%s: override for %s points at unknown target %q", path, user, name)
+		}
+	}
+	return nil
+}
+
+// checkTargets makes sure every target's guild is reachable and its category
+// is a category in that guild, so a bad ID is caught at startup rather than
+// on the first DM. With threads only TICKET_CHANNEL_ID matters.
+func checkTargets(s *discordgo.Session) error {
+	for _, t := range config.Targets {
+		if _, err := s.Guild(t.GuildID); err != nil {
+			return fmt.Errorf("target %q: guild %s is not reachable, is the bot in it? %w", t.Name, t.GuildID, err)
+		}
+		if features.Threads { continue }
+		if err := checkChannel(s, t.CategoryID, t.GuildID, discordgo.ChannelTypeGuildCategory); err != nil {
+			return fmt.Errorf("target %q: %w", t.Name, err)
+		}
+	}
+	if features.Threads {
+		return checkChannel(s, TicketChannelID, GuildID, discordgo.ChannelTypeGuildText)
+	}
+	return nil
+}
+
+func checkChannel(s *discordgo.Session, channelID, guildID string, kind discordgo.ChannelType) error {
+	ch, err := s.Channel(channelID)
+	switch {
+	case err != nil:
+		return fmt.Errorf("channel %s not found: %w", channelID, err)
+	case ch.GuildID != guildID:
+		return fmt.Errorf("channel %s is not in guild %s", channelID, guildID)
+	case ch.Type != kind:
+		return fmt.Errorf("channel %s (%s) is the wrong kind of channel", channelID, ch.Name)
+	}
+	return nil
+}
+
+func targetByName(name string) (Target, bool) {
+	for _, t := range config.Targets {
+		if t.Name == name { return t, true }
+	}
+	return Target{}, false
+}
+
+// resolveTarget picks where a user's ticket should be opened.
+func resolveTarget(userID string) Target {
+	if name, ok := config.Overrides[userID]; ok {
+		if t, ok := targetByName(name); ok { return t }
+	}
+	return config.Targets[0]
+}
+
+// isTicketCategory reports whether categoryID belongs to any target, including
+// overflow categories.
+func isTicketCategory(categoryID string) bool {
+	for _, t := range config.Targets {
+		if t.CategoryID == categoryID { return true }
+	}
+	return isOverflowCategory(categoryID)
+}
+
+// staffGuilds lists each configured guild the bot is still in once.
+func staffGuilds() []string {
+	seen := map[string]bool{}
+	var guilds []string
+	for _, t := range config.Targets {
+		if !seen[t.GuildID] && guildAvailable(t.GuildID) {
+			seen[t.GuildID] = true
+			guilds = append(guilds, t.GuildID)
+		}
+	}
+	return guilds
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxCooldownQueue caps how many DMs are held per user during a cooldown;
+// anything past it is dropped.
+const maxCooldownQueue = 10
+
+// cooldowns holds DMs from users whose ticket was closed less than
+// REOPEN_COOLDOWN_SECONDS ago, until the cooldown runs out.
+var cooldowns = struct {
+	sync.Mutex
+	byUser map[string][]*discordgo.MessageCreate
+}{byUser: map[string][]*discordgo.MessageCreate{}}
+
+// holdForCooldown queues m if its author's last ticket closed too recently to
+// open a new one. Callers hold the user's lock.
+func holdForCooldown(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if ReopenCooldown == 0 { return false }
+	userID := m.Author.ID
+
+	cooldowns.Lock()
+	if queued, ok := cooldowns.byUser[userID]; ok {
+		defer cooldowns.Unlock()
+		if len(queued) >= maxCooldownQueue {
+			sendText(s, m.ChannelID, tr("cooldown_full"))
+			return true
+		}
+		cooldowns.byUser[userID] = append(queued, m)
+		react(s, m.ChannelID, m.ID, "⏳")
+		return true
+	}
+	cooldowns.Unlock()
+
+	closed, err := lastClosedAt(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot check reopen cooldown", "user_id", userID, "err", err)
+		return false
+	}
+	wait := ReopenCooldown - time.Since(closed)
+	if wait <= 0 { return false }
+
+	cooldowns.Lock()
+	cooldowns.byUser[userID] = []*discordgo.MessageCreate{m}
+	cooldowns.Unlock()
+	time.AfterFunc(wait, func() { releaseCooldown(s, userID) })
+
+	slog.Debug("holding message during reopen cooldown", "user_id", userID, "wait", wait)
+	react(s, m.ChannelID, m.ID, "⏳")
+	sendText(s, m.ChannelID, tr("cooldown", wait.Round(time.Second)))
+	return true
+}
+
+// releaseCooldown replays everything a user sent during the cooldown through
+// the usual checks, so the capacity queue and ticket limits still apply.
+func releaseCooldown(s *discordgo.Session, userID string) {
+	// Take the user's lock first so no new DM slips in ahead of the queue
+	unlock := lockUser(userID)
+	defer unlock()
+	cooldowns.Lock()
+	queued := cooldowns.byUser[userID]
+	delete(cooldowns.byUser, userID)
+	cooldowns.Unlock()
+	for _, m := range queued {
+		routeUserDM(s, m)
+	}
+}
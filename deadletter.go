@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	// How many failed log entries wait in memory for a retry
+	deadLetterBuffer = 1000
+	// Retries before an entry is parked in DEAD_LETTER_FILE
+	deadLetterRetries = 5
+)
+
+// Failed log entries that couldn't be retried are appended here, one extended
+// JSON document per line, and replayed on the next start.
+var DeadLetterFile = envOr("DEAD_LETTER_FILE", "failed_logs.jsonl")
+
+var deadLetters = make(chan ModmailLog, deadLetterBuffer)
+
+// insertLog writes entry to MsgCol. Entries carry their own _id, so a retry of
+// an insert that actually landed is treated as success.
+func insertLog(entry ModmailLog) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := MsgCol.InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) { return nil }
+	return err
+}
+
+// queueFailedLog hands entry to the retry worker, or straight to the file if
+// the queue is full.
+func queueFailedLog(entry ModmailLog) {
+	select {
+	case deadLetters <- entry:
+	default:
+		writeDeadLetters([]ModmailLog{entry})
+	}
+}
+
+// startLogRetrier retries failed inserts in the background with exponential
+// backoff, parking entries on disk once they run out of attempts.
+func startLogRetrier() {
+	go func() {
+		for entry := range deadLetters {
+			delay := time.Second
+			var err error
+			for attempt := 0; attempt < deadLetterRetries; attempt++ {
+				time.Sleep(delay)
+				if err = insertLog(entry); err == nil { break }
+				delay = min(delay*2, time.Minute)
+			}
+			if err != nil {
+				slog.Error("giving up on log entry, writing to dead letter file", "user_id", entry.UserID, "file", DeadLetterFile, "err", err)
+				writeDeadLetters([]ModmailLog{entry})
+			}
+		}
+	}()
+}
+
+// flushDeadLetters parks whatever is still queued, for use on shutdown.
+func flushDeadLetters() {
+	var pending []ModmailLog
+	for {
+		select {
+		case entry := <-deadLetters:
+			pending = append(pending, entry)
+		default:
+			if len(pending) > 0 { writeDeadLetters(pending) }
+			return
+		}
+	}
+}
+
+func writeDeadLetters(entries []ModmailLog) {
+	f, err := os.OpenFile(DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		slog.Error("cannot open dead letter file, log entries lost", "file", DeadLetterFile, "count", len(entries), "err", err)
+		return
+	}
+	defer f.Close()
+	for _, entry := range entries {
+		line, err := bson.MarshalExtJSON(entry, true, false)
+		if err == nil { _, err = f.Write(append(line, '\n')) }
+		if err != nil {
+			slog.Error("cannot write dead letter", "user_id", entry.UserID, "err", err)
+		}
+	}
+}
+
+// replayDeadLetters inserts entries parked by a previous run. Anything that
+// still fails stays in the file.
+func replayDeadLetters() {
+	data, err := os.ReadFile(DeadLetterFile)
+	if os.IsNotExist(err) { return }
+	if err != nil {
+		slog.Error("cannot read dead letter file", "file", DeadLetterFile, "err", err)
+		return
+	}
+
+	var failed []ModmailLog
+	replayed := 0
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var entry ModmailLog
+		if err := bson.UnmarshalExtJSON(sc.Bytes(), true, &entry); err != nil {
+			slog.Warn("skipping malformed dead letter", "err", err)
+			continue
+		}
+		if err := insertLog(entry); err != nil {
+			failed = append(failed, entry)
+			continue
+		}
+		replayed++
+	}
+
+	if err := os.Remove(DeadLetterFile); err != nil {
+		slog.Error("cannot clear dead letter file", "file", DeadLetterFile, "err", err)
+		return
+	}
+	if len(failed) > 0 { writeDeadLetters(failed) }
+	slog.Info("replayed dead letters", "replayed", replayed, "still_failing", len(failed))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func messageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	syncDelete(s, m.ID, m.GuildID == "")
+}
+
+func messageDeleteBulk(s *discordgo.Session, m *discordgo.MessageDeleteBulk) {
+	for _, id := range m.Messages {
+		syncDelete(s, id, m.GuildID == "")
+	}
+}
+
+// syncDelete mirrors a deleted message onto its forwarded copy. Staff keep a
+// struck-through record of what the user deleted; a deleted staff reply is
+// removed from the user's DMs. Messages we never forwarded are ignored.
+func syncDelete(s *discordgo.Session, sourceID string, fromUser bool) {
+	link, ok := unlinkMessage(sourceID)
+	if !ok { return }
+
+	for _, id := range link.MessageIDs {
+		var err error
+		if fromUser {
+			err = markDeleted(s, link, id)
+		} else {
+			err = s.ChannelMessageDelete(link.ChannelID, id)
+		}
+		if err != nil && !isUnknownMessage(err) {
+			slog.Warn("cannot sync delete", "message_id", sourceID, "channel_id", link.ChannelID, "err", err)
+		}
+	}
+}
+
+// markDeleted strikes through a forwarded copy and tags it as deleted.
+func markDeleted(s *discordgo.Session, link messageLink, messageID string) error {
+	msg, err := s.ChannelMessage(link.ChannelID, messageID)
+	if err != nil { return err }
+
+	if link.Webhook {
+		wh, err := getOrCreateWebhook(s, link.ChannelID)
+		if err != nil { return err }
+		content := "*[deleted]*"
+		if msg.Content != "" { content = "~~" + truncate(msg.Content, maxMessageLen-20) + "~~ " + content }
+		return withRetry(func() error {
+			_, err := s.WebhookMessageEdit(wh.ID, wh.Token, messageID, &discordgo.WebhookEdit{Content: &content, AllowedMentions: noMentions}, manualRateLimit)
+			return err
+		}, apiAttempts)
+	}
+
+	if len(msg.Embeds) == 0 { return nil }
+	e := msg.Embeds[0]
+	if e.Description != "" { e.Description = "~~" + truncate(e.Description, maxEmbedDesc-4) + "~~" }
+	e.Footer = &discordgo.MessageEmbedFooter{Text: "[deleted]"}
+	e.Color = 0x95a5a6
+	return withRetry(func() error {
+		_, err := s.ChannelMessageEditEmbed(link.ChannelID, messageID, e, manualRateLimit)
+		return err
+	}, apiAttempts)
+}
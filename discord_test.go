@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sentMessage is the JSON body of a message a test session sent.
+type sentMessage struct {
+	Content         string                    `json:"content"`
+	Embeds          []*discordgo.MessageEmbed `json:"embeds"`
+	AllowedMentions *struct {
+		Parse []string `json:"parse"`
+	} `json:"allowed_mentions"`
+}
+
+// recorder answers every Discord API call with an empty message and keeps
+// the bodies of message sends.
+type recorder struct {
+	mu   sync.Mutex
+	sent []sentMessage
+	t    *testing.T
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/messages") {
+		var msg sentMessage
+		body, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &msg); err != nil { r.t.Errorf("cannot decode message body %s: %v", body, err) }
+		r.mu.Lock()
+		r.sent = append(r.sent, msg)
+		r.mu.Unlock()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"1","channel_id":"2"}`)),
+		Request:    req,
+	}, nil
+}
+
+// testSession is a session whose REST calls go to a recorder.
+func testSession(t *testing.T) (*discordgo.Session, *recorder) {
+	s, err := discordgo.New("Bot test")
+	if err != nil { t.Fatal(err) }
+	rec := &recorder{t: t}
+	s.Client = &http.Client{Transport: rec}
+	return s, rec
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userTicketChannels groups the live ticket channels in every staff guild by
+// user. Thread tickets aren't listed by Discord this way, so they're skipped.
+func userTicketChannels(s *discordgo.Session) map[string][]*discordgo.Channel {
+	byUser := map[string][]*discordgo.Channel{}
+	if features.Threads { return byUser }
+	for _, guildID := range staffGuilds() {
+		for _, ch := range guildChannels(s, guildID) {
+			if ArchiveCategoryID != "" && ch.ParentID == ArchiveCategoryID { continue }
+			if userID := ticketUser(s, ch.ID); userID != "" { byUser[userID] = append(byUser[userID], ch) }
+		}
+	}
+	return byUser
+}
+
+// canonicalChannel picks which of a user's channels is the real ticket: the
+// one the tickets collection points at, or else the oldest.
+func canonicalChannel(userID string, channels []*discordgo.Channel) *discordgo.Channel {
+	if t, _ := findOpenTicket(userID); t != nil {
+		for _, ch := range channels {
+			if ch.ID == t.ChannelID { return ch }
+		}
+	}
+	// Snowflakes sort by creation time once they're the same length
+	return slices.MinFunc(channels, func(a, b *discordgo.Channel) int {
+		if len(a.ID) != len(b.ID) { return len(a.ID) - len(b.ID) }
+		if a.ID < b.ID { return -1 }
+		if a.ID > b.ID { return 1 }
+		return 0
+	})
+}
+
+// reconcileDuplicates runs at startup. For every user with more than one live
+// ticket channel it points the tickets collection at the canonical channel
+// and asks staff in the others to !merge once they've copied anything useful.
+func reconcileDuplicates(s *discordgo.Session) {
+	for userID, channels := range userTicketChannels(s) {
+		if len(channels) < 2 { continue }
+		keep := canonicalChannel(userID, channels)
+		slog.Warn("duplicate ticket channels", "user_id", userID, "channels", len(channels), "canonical", keep.ID)
+		pointTicketAt(userID, keep)
+		for _, ch := range channels {
+			if ch.ID == keep.ID { continue }
+			sendText(s, ch.ID, fmt.Sprintf("⚠️ <@%s> has another ticket open in <#%s>, which is the one in use. Copy over anything you need, then run `%smerge` here to close this duplicate.", userID, keep.ID, Prefix))
+		}
+		alertAdmins(s, fmt.Sprintf("⚠️ <@%s> has %d ticket channels open; <#%s> is being kept.", userID, len(channels), keep.ID))
+	}
+}
+
+// pointTicketAt makes ch the user's open ticket in the tickets collection.
+func pointTicketAt(userID string, ch *discordgo.Channel) {
+	t, err := findOpenTicket(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", userID, "err", err)
+		return
+	}
+	if t != nil && t.ChannelID == ch.ID { return }
+	if t != nil { markTicketClosed(t.ChannelID, "", "merged into "+ch.ID) }
+	if err := saveTicket(Ticket{UserID: userID, GuildID: ch.GuildID, ChannelID: ch.ID}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot repoint ticket", "user_id", userID, "channel_id", ch.ID, "err", err)
+	}
+}
+
+// cmdMerge retires a duplicate ticket channel in favour of the user's
+// canonical one, which is left pointed at in the tickets collection.
+func cmdMerge(c *commandContext) {
+	channels := userTicketChannels(c.s)[c.userID]
+	if len(channels) < 2 {
+		sendText(c.s, c.m.ChannelID, "✅ This is the user's only ticket channel, there's nothing to merge.")
+		return
+	}
+	keep := canonicalChannel(c.userID, channels)
+	if keep.ID == c.m.ChannelID {
+		sendText(c.s, c.m.ChannelID, "This is the ticket in use. Run `"+Prefix+"merge` in the duplicate channel instead.")
+		return
+	}
+
+	pointTicketAt(c.userID, keep)
+	markTicketClosed(c.m.ChannelID, c.m.Author.ID, "merged into "+keep.ID)
+	logToDB(c.userID, fmt.Sprintf("Duplicate ticket channel %s merged into %s by %s", c.m.ChannelID, keep.ID, c.m.Author.Username), "system", false)
+	slog.Info("merged duplicate ticket", "user_id", c.userID, "channel_id", c.m.ChannelID, "into", keep.ID, "by", c.m.Author.ID)
+	sendText(c.s, keep.ID, fmt.Sprintf("🔀 A duplicate ticket channel for this user was merged into this one by %s.", c.m.Author.Mention()))
+	logAction(c.s, modEvent{Action: "merge", Actor: c.m.Author, UserID: c.userID, ChannelID: keep.ID,
+		Detail: "Duplicate channel `" + c.m.ChannelID + "` closed"})
+	archiveTicketChannel(c.s, c.m.ChannelID)
+}
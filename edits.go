@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func messageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Embed unfurls also fire updates; only real edits carry an edit timestamp
+	if m.Author == nil || m.EditedTimestamp == nil { return }
+	if m.Author.ID == s.State.User.ID || m.Author.Bot || m.WebhookID != "" { return }
+
+	if m.GuildID == "" {
+		syncUserEdit(s, m)
+	} else {
+		syncStaffEdit(s, m)
+	}
+}
+
+// syncUserEdit re-renders an edited DM over the copy forwarded to staff.
+func syncUserEdit(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	link, ok := linkedMessage(m.ID)
+	if !ok { return }
+
+	var ids []string
+	var err error
+	text, files := withStickers(m.Content, m.Attachments, m.StickerItems)
+	shown, _ := redact(text)
+	content := replyQuote(s, m.Message) + shown + linkPreviews(s, shown)
+	if link.Webhook {
+		ids, err = editWebhookForwarded(s, link, m.Author.Username, m.Author.AvatarURL(""), content+" *(edited)*", files)
+	} else {
+		embeds := userEmbeds(m.Author, content, files)
+		for _, e := range embeds {
+			e.Footer = &discordgo.MessageEmbedFooter{Text: "(edited)"}
+		}
+		ids, err = editForwarded(s, link, embeds)
+	}
+	if err != nil {
+		slog.Warn("cannot sync edit", "message_id", m.ID, "channel_id", link.ChannelID, "err", err)
+	}
+	if len(ids) > 0 {
+		link.MessageIDs = ids
+		linkMessage(m.ID, link)
+	}
+	logRevision(ModmailLog{UserID: m.Author.ID, Content: text, Sender: "user", HasFile: len(files) > 0}, m.ID)
+}
+
+// syncStaffEdit carries an edited staff reply over to the user's DM and logs
+// the new wording as a revision. Commands are never relayed, so their edits
+// are skipped.
+func syncStaffEdit(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	if strings.HasPrefix(m.Content, Prefix) { return }
+	link, ok := linkedMessage(m.ID)
+	if !ok { return }
+	userID := ticketUser(s, m.ChannelID)
+	if userID == "" { return }
+
+	var author *discordgo.User
+	if !link.Anonymous { author = m.Author }
+	embeds := staffEmbeds(portableEmoji(s, replyQuote(s, m.Message)+m.Content), linkedFiles(m.Attachments), author)
+	for _, e := range embeds {
+		e.Footer = &discordgo.MessageEmbedFooter{Text: "(edited)"}
+	}
+	ids, err := editForwarded(s, link, embeds)
+	if err != nil {
+		slog.Warn("cannot sync edit", "message_id", m.ID, "channel_id", link.ChannelID, "err", err)
+	}
+	if len(ids) > 0 {
+		link.MessageIDs = ids
+		linkMessage(m.ID, link)
+	}
+	logRevision(ModmailLog{UserID: userID, Content: m.Content, Sender: "staff", HasFile: len(m.Attachments) > 0, Anonymous: link.Anonymous}, m.ID)
+}
+
+// logRevision logs entry as the new wording of messageID. It revises the
+// latest entry for the message, so the original and every edit are kept in
+// order. Messages logged before entries recorded their message ID start a
+// chain of their own.
+func logRevision(entry ModmailLog, messageID string) {
+	entry.MessageID, entry.EditOf = messageID, messageID
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var prev ModmailLog
+	err := MsgCol.FindOne(ctx, bson.M{"message_id": messageID}, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})).Decode(&prev)
+	switch {
+	case err == nil:
+		entry.Revises = prev.ID
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		dbErrors.Inc()
+		slog.Warn("cannot find edited message in the log", "message_id", messageID, "err", err)
+	}
+	saveLog(entry)
+}
+
+// editForwarded replaces the embeds behind link with embeds, sending new
+// messages for any extra chunks and deleting leftover ones. If the original
+// forward was deleted, the edit is posted as a fresh message instead. It
+// returns the message IDs that now hold the content.
+func editForwarded(s *discordgo.Session, link messageLink, embeds []*discordgo.MessageEmbed) ([]string, error) {
+	var ids []string
+	groups := groupEmbeds(embeds)
+	for i, group := range groups {
+		if i < len(link.MessageIDs) {
+			var msg *discordgo.Message
+			err := withRetry(func() (err error) {
+				msg, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{ID: link.MessageIDs[i], Channel: link.ChannelID, Embeds: &group}, manualRateLimit)
+				return err
+			}, apiAttempts)
+			if err == nil {
+				ids = append(ids, msg.ID)
+				continue
+			}
+			if !isUnknownMessage(err) { return ids, err }
+		}
+		msg, err := sendEmbedGroup(s, link.ChannelID, group)
+		if err != nil { return ids, err }
+		ids = append(ids, msg.ID)
+	}
+	for i := len(groups); i < len(link.MessageIDs); i++ {
+		s.ChannelMessageDelete(link.ChannelID, link.MessageIDs[i])
+	}
+	return ids, nil
+}
+
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
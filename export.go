@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// exportTimeout is longer than dbTimeout since heavy users can have a lot of history.
+const exportTimeout = 2 * time.Minute
+
+// exportRow is one log entry as it appears in an export.
+type exportRow struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Sender    string    `json:"sender"`
+	Content   string    `json:"content"`
+	HasFile   bool      `json:"has_file"`
+	Anonymous bool      `json:"anonymous"`
+	EditOf    string    `json:"edit_of,omitempty"`
+	Revises   string    `json:"revises,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// cmdExport attaches a user's full message log as JSON (the default) or CSV.
+func cmdExport(c *commandContext) {
+	target, format := c.userID, "json"
+	for _, arg := range c.args {
+		switch a := strings.ToLower(arg); a {
+		case "json", "csv":
+			format = a
+		default:
+			target = strings.Trim(arg, "<@!>")
+		}
+	}
+
+	f, n, err := exportLogs(target, format)
+	if f != nil {
+		defer os.Remove(f.Name())
+		defer f.Close()
+	}
+	if err != nil {
+		slog.Error("cannot export logs", "user_id", target, "format", format, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not export the log.")
+		return
+	}
+
+	_, err = sendMessage(c.s, c.m.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("📦 Exported %d messages for <@%s>.", n, target),
+		Files:           []*discordgo.File{{Name: fmt.Sprintf("modmail-%s.%s", target, format), Reader: f}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error("cannot upload export", "user_id", target, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not upload the export; it may be too large for Discord.")
+	}
+}
+
+// exportLogs streams the user's log into a temporary file, one cursor batch at
+// a time, and returns it rewound along with the number of entries written.
+func exportLogs(userID, format string) (*os.File, int, error) {
+	f, err := os.CreateTemp("", "modmail-export-*")
+	if err != nil { return nil, 0, err }
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	cur, err := MsgCol.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil { return f, 0, err }
+	defer cur.Close(ctx)
+
+	var write func(exportRow) error
+	var finish func() error
+	switch format {
+	case "csv":
+		w := csv.NewWriter(f)
+		w.Write([]string{"id", "user_id", "sender", "content", "has_file", "anonymous", "edit_of", "revises", "timestamp"})
+		write = func(r exportRow) error {
+			return w.Write([]string{r.ID, r.UserID, r.Sender, r.Content, strconv.FormatBool(r.HasFile),
+				strconv.FormatBool(r.Anonymous), r.EditOf, r.Revises, r.Timestamp.UTC().Format(time.RFC3339)})
+		}
+		finish = func() error { w.Flush(); return w.Error() }
+	default:
+		io.WriteString(f, "[\n")
+		enc := json.NewEncoder(f)
+		first := true
+		write = func(r exportRow) error {
+			if !first { io.WriteString(f, ",") }
+			first = false
+			return enc.Encode(r)
+		}
+		finish = func() error { _, err := io.WriteString(f, "]\n"); return err }
+	}
+
+	n := 0
+	for cur.Next(ctx) {
+		var l ModmailLog
+		if err := cur.Decode(&l); err != nil { return f, n, err }
+		row := exportRow{l.ID.Hex(), l.UserID, l.Sender, l.Content, l.HasFile, l.Anonymous, l.EditOf, "", l.Timestamp}
+		if !l.Revises.IsZero() { row.Revises = l.Revises.Hex() }
+		if err := write(row); err != nil { return f, n, err }
+		n++
+	}
+	if err := cur.Err(); err != nil { return f, n, err }
+	if err := finish(); err != nil { return f, n, err }
+	_, err = f.Seek(0, io.SeekStart)
+	return f, n, err
+}
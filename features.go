@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// Features are the optional behaviours a deployment can switch on, each from
+// FEATURE_<NAME>=true|false. The older variable named alongside each flag is
+// still honoured when the FEATURE_ one isn't set.
+type Features struct {
+	Claiming          bool // CLAIMING: staff /claim tickets so only the claimer replies
+	AutoClose         bool // on by default when AUTO_CLOSE_HOURS is set
+	Webhooks          bool // USE_WEBHOOKS: post forwarded messages under the sender's name
+	Threads           bool // USE_THREADS: private threads under TICKET_CHANNEL_ID instead of channels
+	DMReceipts        bool // DM_RECEIPTS: react to users' DMs once they reach staff
+	Reupload          bool // REUPLOAD_ATTACHMENTS: copy user attachments before CDN links expire
+	RequireMembership bool // REQUIRE_MEMBERSHIP: only MAIN_GUILD_ID members can open tickets
+	UserClose         bool // ALLOW_USER_CLOSE: a button on the welcome message lets users close their ticket
+	TranscriptThreads bool // on by default: each transcript in LOG_CHANNEL_ID gets its own thread
+	UserTranscripts   bool // DM_TRANSCRIPT_TO_USER, on by default: users are sent a copy of the transcript on close
+}
+
+var features = Features{
+	Claiming:          featureFlag("CLAIMING", os.Getenv("CLAIMING") == "true"),
+	AutoClose:         featureFlag("AUTOCLOSE", AutoCloseAfter > 0),
+	Webhooks:          featureFlag("WEBHOOKS", os.Getenv("USE_WEBHOOKS") == "true"),
+	Threads:           featureFlag("THREADS", os.Getenv("USE_THREADS") == "true"),
+	DMReceipts:        featureFlag("DM_RECEIPTS", os.Getenv("DM_RECEIPTS") == "true"),
+	Reupload:          featureFlag("REUPLOAD_ATTACHMENTS", os.Getenv("REUPLOAD_ATTACHMENTS") == "true"),
+	RequireMembership: featureFlag("REQUIRE_MEMBERSHIP", os.Getenv("REQUIRE_MEMBERSHIP") == "true"),
+	UserClose:         featureFlag("USER_CLOSE", os.Getenv("ALLOW_USER_CLOSE") == "true"),
+	TranscriptThreads: featureFlag("TRANSCRIPT_THREADS", true),
+	UserTranscripts:   featureFlag("USER_TRANSCRIPTS", os.Getenv("DM_TRANSCRIPT_TO_USER") != "false"),
+}
+
+// featureFlag reads FEATURE_<name>, falling back to def when it's unset.
+func featureFlag(name string, def bool) bool {
+	switch os.Getenv("FEATURE_" + name) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return def
+}
+
+// validate checks that every enabled feature has the settings it needs.
+func (f Features) validate() error {
+	var errs []error
+	if f.Threads && TicketChannelID == "" { errs = append(errs, errors.New("threads need TICKET_CHANNEL_ID")) }
+	if !f.Threads && CategoryID == "" { errs = append(errs, errors.New("CATEGORY_ID is required unless threads are enabled")) }
+	if f.AutoClose && AutoCloseAfter == 0 { errs = append(errs, errors.New("auto-close needs AUTO_CLOSE_HOURS")) }
+	return errors.Join(errs...)
+}
+
+// enabled names the features that are on, for the startup log.
+func (f Features) enabled() []string {
+	var names []string
+	for _, x := range []struct {
+		name string
+		on   bool
+	}{
+		{"claiming", f.Claiming}, {"autoclose", f.AutoClose}, {"webhooks", f.Webhooks}, {"threads", f.Threads},
+		{"dm_receipts", f.DMReceipts}, {"reupload_attachments", f.Reupload}, {"require_membership", f.RequireMembership},
+		{"user_close", f.UserClose}, {"transcript_threads", f.TranscriptThreads}, {"user_transcripts", f.UserTranscripts},
+	} {
+		if x.on { names = append(names, x.name) }
+	}
+	return names
+}
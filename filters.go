@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// FlagFiltered posts a notice in the ticket when a user's message was redacted
+var FlagFiltered = envOr("FLAG_FILTERED", "true") != "false"
+
+// ContentFilter is one entry in the content_filters collection. Words match
+// whole words, case-insensitively; regexes are used as written.
+type ContentFilter struct {
+	Pattern string    `bson:"_id"`
+	Regex   bool      `bson:"regex"`
+	AddedBy string    `bson:"added_by"`
+	AddedAt time.Time `bson:"added_at"`
+}
+
+// compile turns f into the expression that redact runs.
+func (f ContentFilter) compile() (*regexp.Regexp, error) {
+	if f.Regex { return regexp.Compile(f.Pattern) }
+	return regexp.Compile(`(?i)\b` + regexp.QuoteMeta(f.Pattern) + `\b`)
+}
+
+// filters mirrors content_filters, compiled, so forwarding needs no database
+// round trip.
+var filters = struct {
+	sync.RWMutex
+	list []*regexp.Regexp
+}{}
+
+// loadFilters re-reads content_filters. Entries that no longer compile are
+// skipped with a warning rather than failing the whole list.
+func loadFilters() error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := FilterCol.Find(ctx, bson.M{})
+	if err != nil { return err }
+	var entries []ContentFilter
+	if err = cur.All(ctx, &entries); err != nil { return err }
+
+	var list []*regexp.Regexp
+	for _, f := range entries {
+		re, err := f.compile()
+		if err != nil {
+			slog.Warn("skipping invalid content filter", "pattern", f.Pattern, "err", err)
+			continue
+		}
+		list = append(list, re)
+	}
+	filters.Lock()
+	filters.list = list
+	filters.Unlock()
+	return nil
+}
+
+func filterCount() int {
+	filters.RLock()
+	defer filters.RUnlock()
+	return len(filters.list)
+}
+
+// redact replaces every filtered match in content with ***. It reports
+// whether anything was replaced.
+func redact(content string) (string, bool) {
+	filters.RLock()
+	defer filters.RUnlock()
+	out := content
+	for _, re := range filters.list {
+		out = re.ReplaceAllLiteralString(out, "***")
+	}
+	return out, out != content
+}
+
+// cmdFilter manages the content filters: "filter add <word>", "filter regex
+// <expression>", "filter remove <word or expression>", "filter list" and
+// "filter reload". Users' messages are redacted before staff see them; the
+// log keeps the original.
+func cmdFilter(c *commandContext) {
+	usage := "Usage: `" + Prefix + "filter add <word>`, `" + Prefix + "filter regex <expression>`, `" +
+		Prefix + "filter remove <pattern>`, `" + Prefix + "filter list` or `" + Prefix + "filter reload`"
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	pattern := skipFields(c.rest, 1)
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	var err error
+	switch sub := strings.ToLower(c.args[0]); {
+	case sub == "list":
+		var entries []ContentFilter
+		cur, ferr := FilterCol.Find(ctx, bson.M{})
+		if err = ferr; err == nil { err = cur.All(ctx, &entries) }
+		if err != nil { break }
+		lines := []string{fmt.Sprintf("🧹 %d content filters", len(entries))}
+		for _, f := range entries {
+			kind := "word"
+			if f.Regex { kind = "regex" }
+			lines = append(lines, fmt.Sprintf("`%s` (%s, added by <@%s>)", strings.ReplaceAll(f.Pattern, "`", "'"), kind, f.AddedBy))
+		}
+		sendText(c.s, c.m.ChannelID, strings.Join(lines, "\n"))
+		return
+	case sub == "reload":
+		err = loadFilters()
+	case (sub == "add" || sub == "regex") && pattern != "":
+		f := ContentFilter{Pattern: pattern, Regex: sub == "regex", AddedBy: c.m.Author.ID, AddedAt: time.Now()}
+		if _, cerr := f.compile(); cerr != nil {
+			sendText(c.s, c.m.ChannelID, "❌ Invalid expression: "+cerr.Error())
+			return
+		}
+		if _, err = FilterCol.InsertOne(ctx, f); mongo.IsDuplicateKeyError(err) {
+			sendText(c.s, c.m.ChannelID, "That filter already exists.")
+			return
+		}
+		if err == nil { err = loadFilters() }
+	case sub == "remove" && pattern != "":
+		var res *mongo.DeleteResult
+		if res, err = FilterCol.DeleteOne(ctx, bson.M{"_id": pattern}); err == nil && res.DeletedCount == 0 {
+			sendText(c.s, c.m.ChannelID, "❌ No such filter.")
+			return
+		}
+		if err == nil { err = loadFilters() }
+	default:
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot update content filters", "command", c.rest, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update the filters.")
+		return
+	}
+	slog.Info("content filters changed", "by", c.m.Author.ID, "command", c.args[0], "active", filterCount())
+	sendText(c.s, c.m.ChannelID, fmt.Sprintf("✅ %d content filters active.", filterCount()))
+}
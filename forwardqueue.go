@@ -0,0 +1,95 @@
+package main
+
+import (
+	"hash/fnv"
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// Goroutines forwarding messages; each user always lands on the same one
+	ForwardWorkers = max(1, envInt("FORWARD_WORKERS", 4))
+	// Jobs each worker buffers before handlers start waiting for room
+	ForwardQueueSize = max(1, envInt("FORWARD_QUEUE_SIZE", 100))
+)
+
+// forwarders run the slow part of forwarding (channel creation, sends, DB
+// writes) off the event handlers. Jobs are sharded by user ID, so one user's
+// messages are forwarded in the order they were queued.
+var forwarders = struct {
+	sync.RWMutex
+	queues  []chan func()
+	stopped bool
+	wg      sync.WaitGroup
+}{}
+
+var (
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "modmail_forward_queue_depth",
+		Help: "Forwarding jobs waiting for a worker.",
+	}, forwardQueueDepth)
+	forwardQueueFull = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_forward_queue_full_total",
+		Help: "Times a handler had to wait because its forwarding queue was full.",
+	})
+)
+
+func startForwarders() {
+	forwarders.queues = make([]chan func(), ForwardWorkers)
+	for i := range forwarders.queues {
+		q := make(chan func(), ForwardQueueSize)
+		forwarders.queues[i] = q
+		forwarders.wg.Add(1)
+		go func() {
+			defer forwarders.wg.Done()
+			for job := range q {
+				job()
+			}
+		}()
+	}
+}
+
+// enqueueForward queues job on userID's worker, waiting for room when the
+// queue is full. After shutdown has begun the job runs inline instead.
+func enqueueForward(userID string, job func()) {
+	forwarders.RLock()
+	defer forwarders.RUnlock()
+	if forwarders.stopped || len(forwarders.queues) == 0 {
+		job()
+		return
+	}
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	q := forwarders.queues[h.Sum32()%uint32(len(forwarders.queues))]
+	select {
+	case q <- job:
+	default:
+		forwardQueueFull.Inc()
+		slog.Warn("forward queue full, waiting", "user_id", userID, "depth", len(q))
+		q <- job
+	}
+}
+
+// stopForwarders finishes every queued job and stops the workers.
+func stopForwarders() {
+	forwarders.Lock()
+	forwarders.stopped = true
+	for _, q := range forwarders.queues {
+		close(q)
+	}
+	forwarders.Unlock()
+	forwarders.wg.Wait()
+}
+
+func forwardQueueDepth() float64 {
+	forwarders.RLock()
+	defer forwarders.RUnlock()
+	n := 0
+	for _, q := range forwarders.queues {
+		n += len(q)
+	}
+	return float64(n)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errGuildGone is returned for work in a guild the bot has been removed from.
+var errGuildGone = errors.New("the bot is no longer in this guild")
+
+// lostGuilds are guilds the bot was kicked from, or that were deleted, since
+// it started. Nothing is attempted in them until the bot is added back.
+var lostGuilds = struct {
+	sync.RWMutex
+	ids map[string]bool
+}{ids: map[string]bool{}}
+
+func guildAvailable(guildID string) bool {
+	lostGuilds.RLock()
+	defer lostGuilds.RUnlock()
+	return !lostGuilds.ids[guildID]
+}
+
+// guildDelete fires when the bot leaves a guild, and also when a guild drops
+// out in a Discord outage; only the former marks it lost.
+func guildDelete(s *discordgo.Session, g *discordgo.GuildDelete) {
+	if g.Unavailable {
+		slog.Warn("guild unavailable, likely a Discord outage", "guild_id", g.ID)
+		return
+	}
+	if !isStaffGuild(g.ID) && g.ID != MainGuildID {
+		slog.Info("removed from guild", "guild_id", g.ID)
+		return
+	}
+	lostGuilds.Lock()
+	lostGuilds.ids[g.ID] = true
+	lostGuilds.Unlock()
+
+	if g.ID == GuildID || len(staffGuilds()) == 0 {
+		slog.Error("REMOVED FROM THE STAFF GUILD: no tickets can be opened until the bot is added back", "guild_id", g.ID)
+		return
+	}
+	slog.Error("removed from a configured guild, its tickets are unavailable", "guild_id", g.ID)
+}
+
+// guildCreate clears a lost guild once the bot is added back.
+func guildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	lostGuilds.Lock()
+	lost := lostGuilds.ids[g.ID]
+	delete(lostGuilds.ids, g.ID)
+	lostGuilds.Unlock()
+	if lost { slog.Info("added back to guild", "guild_id", g.ID, "name", g.Name) }
+}
+
+// isStaffGuild reports whether guildID holds any ticket target, whether or
+// not the bot is still in it.
+func isStaffGuild(guildID string) bool {
+	for _, t := range config.Targets {
+		if t.GuildID == guildID { return true }
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var startedAt = time.Now()
+
+// gateway tracks the Discord connection as seen through gateway events.
+var gateway = struct {
+	sync.RWMutex
+	connected bool
+	since     time.Time
+}{}
+
+func setGateway(connected bool) {
+	gateway.Lock()
+	defer gateway.Unlock()
+	if gateway.connected == connected { return }
+	gateway.connected, gateway.since = connected, time.Now()
+}
+
+func gatewayUp() (bool, time.Time) {
+	gateway.RLock()
+	defer gateway.RUnlock()
+	return gateway.connected, gateway.since
+}
+
+func onConnect(s *discordgo.Session, _ *discordgo.Connect) {
+	slog.Info("gateway connected")
+	if _, since := gatewayUp(); !since.IsZero() { gatewayReconnects.Inc() }
+	setGateway(true)
+}
+
+func onDisconnect(s *discordgo.Session, _ *discordgo.Disconnect) {
+	slog.Warn("gateway disconnected, waiting for reconnect")
+	setGateway(false)
+}
+
+func onResumed(s *discordgo.Session, _ *discordgo.Resumed) {
+	slog.Info("gateway session resumed")
+	gatewayReconnects.Inc()
+	setGateway(true)
+}
+
+// handleRoot is the plain liveness page; it fails while the gateway is down.
+func handleRoot(w http.ResponseWriter, r *http.Request) {
+	if up, _ := gatewayUp(); !up {
+		http.Error(w, "Modmail Bot Disconnected", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintf(w, "Modmail Bot Active")
+}
+
+// handleHealthz reports gateway and MongoDB status as JSON.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	up, since := gatewayUp()
+	status := struct {
+		Gateway      string `json:"gateway"`
+		GatewaySince string `json:"gateway_since,omitempty"`
+		Mongo        string `json:"mongo"`
+		Uptime       string `json:"uptime"`
+		ChannelCache struct {
+			Hits   int64 `json:"hits"`
+			Misses int64 `json:"misses"`
+		} `json:"channel_cache"`
+	}{Gateway: "connected", Mongo: "ok", Uptime: time.Since(startedAt).Round(time.Second).String()}
+	status.ChannelCache.Hits, status.ChannelCache.Misses = channelCacheStats()
+	if !up { status.Gateway = "disconnected" }
+	if !since.IsZero() { status.GatewaySince = since.Format(time.RFC3339) }
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	if err := MsgCol.Database().Client().Ping(ctx, nil); err != nil {
+		status.Mongo = err.Error()
+		up = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !up { w.WriteHeader(http.StatusServiceUnavailable) }
+	json.NewEncoder(w).Encode(status)
+}
+
+// startKeepalive fetches KEEPALIVE_URL, usually the bot's own public address,
+// every KEEPALIVE_MINUTES. Failures are only logged; the next tick tries again.
+func startKeepalive() {
+	if KeepaliveURL == "" || KeepaliveInterval <= 0 { return }
+	client := &http.Client{Timeout: 30 * time.Second}
+	slog.Info("keepalive enabled", "url", KeepaliveURL, "interval", KeepaliveInterval)
+	go func() {
+		for range time.Tick(KeepaliveInterval) {
+			resp, err := client.Get(KeepaliveURL)
+			if err != nil {
+				slog.Warn("keepalive request failed", "url", KeepaliveURL, "err", err)
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				slog.Warn("keepalive request failed", "url", KeepaliveURL, "status", resp.StatusCode)
+			}
+		}
+	}()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// historyTickets is how many recent tickets !history lists.
+const historyTickets = 5
+
+// cmdHistory posts a snapshot of a user's past tickets and block record, so
+// staff can spot repeat openers at a glance.
+func cmdHistory(c *commandContext) {
+	userID := c.userID
+	if len(c.args) > 0 { userID = strings.Trim(c.args[0], "<@!>") }
+
+	embed, err := historyEmbed(userID)
+	if err != nil {
+		slog.Error("cannot load ticket history", "user_id", userID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not load the history.")
+		return
+	}
+	sendEmbed(c.s, c.m.ChannelID, embed)
+}
+
+func historyEmbed(userID string) (*discordgo.MessageEmbed, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	total, err := TicketCol.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil { return nil, err }
+	cur, err := TicketCol.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(historyTickets))
+	if err != nil { return nil, err }
+	var tickets []Ticket
+	if err = cur.All(ctx, &tickets); err != nil { return nil, err }
+
+	// Averages over the closed tickets that have a ticket_stats summary
+	cur, err = TicketStatsCol.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"user_id": userID, "closed_at": bson.M{"$exists": true}}},
+		bson.M{"$group": bson.M{
+			"_id":      nil,
+			"messages": bson.M{"$avg": "$message_count"},
+			"response": bson.M{"$avg": "$first_response_latency"},
+		}},
+	})
+	if err != nil { return nil, err }
+	var averages []struct {
+		Messages float64 `bson:"messages"`
+		Response float64 `bson:"response"`
+	}
+	if err = cur.All(ctx, &averages); err != nil { return nil, err }
+
+	block, err := blockRecord(userID)
+	if err != nil { return nil, err }
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🗂️ Ticket history",
+		Description: fmt.Sprintf("<@%s> · %s", userID, plural(int(total), "ticket")),
+		Color: 0x95a5a6,
+	}
+
+	var lines []string
+	for _, t := range tickets {
+		line := fmt.Sprintf("`#%04d` opened %s", t.Number, fmtDate(t.CreatedAt))
+		switch {
+		case t.Open:
+			line += " · **open**"
+		case !t.ClosedAt.IsZero():
+			line += " · closed " + fmtTime(t.ClosedAt)
+		}
+		if t.CloseReason != "" { line += " · " + truncate(t.CloseReason, 60) }
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Recent tickets", Value: strings.Join(lines, "\n")})
+	}
+
+	if len(averages) > 0 {
+		response := "never answered"
+		if averages[0].Response > 0 { response = formatResponse(time.Duration(averages[0].Response) * time.Millisecond) }
+		embed.Fields = append(embed.Fields,
+			&discordgo.MessageEmbedField{Name: "Avg. messages", Value: fmt.Sprintf("%.1f", averages[0].Messages), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Avg. first response", Value: response, Inline: true},
+		)
+	}
+
+	blocked := "Never"
+	if block != nil {
+		blocked = fmt.Sprintf("⛔ Blocked %s by <@%s>", fmtDate(block.BlockedAt), block.BlockedBy)
+		if !block.UnblockedAt.IsZero() { blocked = "Previously, unblocked " + fmtDate(block.UnblockedAt) }
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Blocked", Value: blocked, Inline: true})
+	return embed, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// ensureIndexes creates every index the bot queries by. Creating an index that
+// already exists is a no-op, so this runs on every start.
+func ensureIndexes() {
+	indexes := []struct {
+		col   *mongo.Collection
+		model mongo.IndexModel
+	}{
+		// History, export and !logs go by user, newest or oldest first
+		{MsgCol, mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}}},
+		{MsgCol, mongo.IndexModel{Keys: bson.D{{Key: "timestamp", Value: 1}}}},
+		{MsgCol, mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: 1}}}},
+		// Edits find the entry they revise
+		{MsgCol, mongo.IndexModel{Keys: bson.D{{Key: "message_id", Value: 1}, {Key: "timestamp", Value: -1}}}},
+		// !search
+		{MsgCol, mongo.IndexModel{Keys: bson.D{{Key: "content", Value: "text"}}}},
+		// At most one open ticket per user
+		{TicketCol, mongo.IndexModel{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"open": true}),
+		}},
+		// Queued users are promoted oldest first
+		{QueueCol, mongo.IndexModel{Keys: bson.D{{Key: "queued_at", Value: 1}}}},
+		// User notes are listed per user
+		{UserNoteCol, mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}}},
+		// Reminders are cancelled by ticket
+		{ReminderCol, mongo.IndexModel{Keys: bson.D{{Key: "channel_id", Value: 1}}}},
+		// Message links are looked up from either end
+		{LinkCol, mongo.IndexModel{Keys: bson.D{{Key: "source_msg_id", Value: 1}, {Key: "part", Value: 1}}}},
+		{LinkCol, mongo.IndexModel{Keys: bson.D{{Key: "dest_msg_id", Value: 1}}}},
+	}
+
+	for _, ix := range indexes {
+		ctx, cancel := dbCtx()
+		_, err := ix.col.Indexes().CreateOne(ctx, ix.model)
+		cancel()
+		if indexConflict(err) {
+			slog.Warn("index exists with different options, keeping it", "collection", ix.col.Name(), "keys", ix.model.Keys, "err", err)
+		} else if err != nil {
+			slog.Error("cannot create index", "collection", ix.col.Name(), "keys", ix.model.Keys, "err", err)
+		}
+	}
+}
+
+// indexConflict reports whether err is MongoDB refusing an index because one
+// on the same keys, or with the same name, already exists.
+func indexConflict(err error) bool {
+	var ce mongo.CommandError
+	if !errors.As(err, &ce) { return false }
+	// IndexAlreadyExists, IndexOptionsConflict, IndexKeySpecsConflict
+	return ce.Code == 68 || ce.Code == 85 || ce.Code == 86
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// cmdInfo posts a summary of the ticket in this channel. Fields older tickets
+// never recorded are left out.
+func cmdInfo(c *commandContext) {
+	t, err := findTicketByChannel(c.m.ChannelID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "channel_id", c.m.ChannelID, "err", err)
+	}
+	if t == nil {
+		sendText(c.s, c.m.ChannelID, "❌ There's no open ticket in this channel.")
+		return
+	}
+	embed, err := ticketInfoEmbed(*t)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load ticket activity", "channel_id", c.m.ChannelID, "err", err)
+	}
+	sendEmbed(c.s, c.m.ChannelID, embed)
+}
+
+// ticketInfoEmbed renders t. If the message log can't be read the embed is
+// still returned, without the activity fields, along with the error.
+func ticketInfoEmbed(t Ticket) (*discordgo.MessageEmbed, error) {
+	title := "🎫 Ticket"
+	if t.Number > 0 { title = fmt.Sprintf("🎫 Ticket #%d", t.Number) }
+	if t.Label != "" { title += " · " + t.Label }
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Color: settings().StaffColor,
+		Fields: []*discordgo.MessageEmbedField{{Name: "User", Value: fmt.Sprintf("<@%s> (%s)", t.UserID, t.UserID), Inline: true}},
+	}
+	add := func(name, value string) {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true})
+	}
+	if !t.CreatedAt.IsZero() { add("Opened", fmtTime(t.CreatedAt)) }
+	if t.OpenedBy != "" { add("Opened by", "<@"+t.OpenedBy+">") }
+	if t.Category != "" { add("Category", t.Category) }
+	if t.Status != "" { add("Status", t.Status) }
+	claimed := "Nobody"
+	if t.ClaimedBy != "" { claimed = "<@" + t.ClaimedBy + ">" }
+	add("Claimed by", claimed)
+	priority := t.Priority
+	if priority == "" { priority = "normal" }
+	add("Priority", priority)
+	add("Tags", formatTags(t.Tags))
+	if !t.FirstStaffResponseAt.IsZero() { add("First response", fmtTime(t.FirstStaffResponseAt)) }
+	if !t.CloseAt.IsZero() { add("Closing", fmtTime(t.CloseAt)) }
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	filter := bson.M{
+		"user_id": t.UserID, "timestamp": bson.M{"$gte": t.CreatedAt},
+		"sender": bson.M{"$in": bson.A{"user", "staff"}}, "edit_of": bson.M{"$exists": false},
+	}
+	n, err := MsgCol.CountDocuments(ctx, filter)
+	if err != nil { return embed, err }
+	add("Messages", fmt.Sprint(n))
+	var last ModmailLog
+	err = MsgCol.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) { return embed, nil }
+	if err != nil { return embed, err }
+	add("Last activity", fmt.Sprintf("%s (%s)", fmtTime(last.Timestamp), last.Sender))
+	return embed, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// catalog holds every string users see, in English. LANG_FILE, a JSON object
+// of key -> text, replaces any of them; keys it leaves out stay English.
+// Texts with %s or %d placeholders are filled in by tr in order.
+var catalog = map[string]string{
+	"welcome_message":      "Your message has been sent to the staff. Please wait for a response.",
+	"created_title":        "🎫 Ticket Created",
+	"staff_title":          "💬 Staff Response",
+	"blocked":              "⛔ You have been blocked from contacting staff.",
+	"slow_down":            "🐢 You're sending messages too quickly. Please wait a few seconds and try again.",
+	"too_many_tickets":     "🐢 You've opened too many tickets recently. Please try again later.",
+	"ticket_closed":        "🔒 Your ticket has been closed.",
+	"ticket_closed_reason": "🔒 Your ticket was closed: %s",
+	"inactive_warning":     "⏳ This ticket has been inactive for a while and will be closed in %s unless there's a reply.",
+	"transcript":           "📝 Here's a copy of your conversation with staff.",
+	"category_title":       "📂 What can we help you with?",
+	"category_prompt":      "Pick the option that best matches your message so it reaches the right team.",
+	"category_expired":     "That option is no longer available.",
+	"category_chosen":      "📂 Category: **%s**",
+	"category_timeout":     "📂 No category chosen, your message was sent to the general queue.",
+	"away_title":           "🌙 Staff are away",
+	"cooldown":             "⏳ Your last ticket was closed moments ago. Your message will be sent to staff in about %s.",
+	"cooldown_full":        "⚠️ This message was not delivered. Please wait until your new ticket opens before sending more.",
+	"capacity":             "🚦 We're at capacity right now. Your message is saved and a ticket will open as soon as staff are free.",
+	"capacity_position":    "🚦 We're at capacity right now, you're in queue position %d. Your message is saved and a ticket will open as soon as staff are free.",
+	"guild_fallback":       "the server",
+	"not_member":           "🚪 Only members of %s can contact staff here.",
+	"undelivered":          "⚠️ Your message couldn't reach staff right now. Please try again later.",
+	"close_button":         "Close ticket",
+	"user_close_confirm":   "🔒 Close your ticket? Staff won't see anything more unless you message again.",
+	"user_close_yes":       "Yes, close it",
+	"user_closing":         "🔒 Closing your ticket.",
+	"no_open_ticket":       "You don't have an open ticket.",
+	"broadcast_title":      "📢 Announcement",
+	"status_claimed":       "🙋 A staff member is handling your ticket.",
+	"resolve_prompt":       "✅ Staff have marked your ticket as resolved. Is everything sorted? It will close in %s unless you reopen it.",
+	"resolve_yes":          "Yes, it's resolved",
+	"resolve_reopen":       "Reopen",
+	"resolve_confirmed":    "✅ Thanks for confirming.",
+	"resolve_reopened":     "🔓 Your ticket is open again, staff will be with you shortly.",
+	"resolve_expired":      "This ticket is no longer waiting for your confirmation.",
+	"resolved_reason":      "Resolved",
+	"queue_lost":           "⚠️ A ticket slot opened up, but your earlier messages could no longer be found. Please send your message again.",
+}
+
+// loadLang merges LANG_FILE over the built-in catalog. It runs once at
+// startup, before any handler reads the catalog, so lookups need no lock.
+func loadLang() error {
+	path := os.Getenv("LANG_FILE")
+	if path == "" { return nil }
+	data, err := os.ReadFile(path)
+	if err != nil { return err }
+
+	var file map[string]string
+	if err = json.Unmarshal(data, &file); err != nil { return fmt.Errorf("%s: %w", path, err) }
+	for key, text := range file {
+		if _, ok := catalog[key]; !ok {
+			slog.Warn("unknown key in language file", "file", path, "key", key)
+			continue
+		}
+		catalog[key] = text
+	}
+
+	// Settings that are shown to users default to the translated text
+	defaultSettings.WelcomeMessage = catalog["welcome_message"]
+	defaultSettings.CreatedTitle = catalog["created_title"]
+	defaultSettings.StaffTitle = catalog["staff_title"]
+	return nil
+}
+
+// tr looks up a user-facing string and fills in its placeholders. An unknown
+// key comes back as is, so a typo shows up rather than an empty message.
+func tr(key string, args ...any) string {
+	text, ok := catalog[key]
+	if !ok { return key }
+	if len(args) == 0 { return text }
+	return fmt.Sprintf(text, args...)
+}
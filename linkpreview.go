@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxLinkPreviews caps how many message links one DM gets previews for.
+const maxLinkPreviews = 3
+
+// messageLinkPattern matches discord.com message links, capturing the guild,
+// channel and message IDs.
+var messageLinkPattern = regexp.MustCompile(`https?://(?:(?:ptb|canary)\.)?discord(?:app)?\.com/channels/(\d+|@me)/(\d+)/(\d+)`)
+
+// linkPreviews quotes the messages that Discord message links in content point
+// at, one line each, so staff don't have to open them. Links the bot can't
+// read are left as plain links.
+func linkPreviews(s *discordgo.Session, content string) string {
+	var lines []string
+	for _, match := range messageLinkPattern.FindAllStringSubmatch(content, maxLinkPreviews) {
+		// Links into someone's DMs are never readable
+		if match[1] == "@me" { continue }
+		msg, err := s.ChannelMessage(match[2], match[3])
+		if err != nil {
+			slog.Debug("cannot preview message link", "channel_id", match[2], "message_id", match[3], "err", err)
+			continue
+		}
+		where := ""
+		if ch := fetchChannel(s, msg.ChannelID); ch != nil { where = " in #" + ch.Name }
+		lines = append(lines, "> 🔗 **"+msg.Author.Username+"**"+where+": "+quotedText(msg))
+	}
+	if len(lines) == 0 { return "" }
+	return "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Link directions
+const (
+	toStaff = "to_staff"
+	toUser  = "to_user"
+)
+
+// messageLink records where a forwarded message ended up. Long messages are
+// split over several embeds, so there can be more than one destination.
+type messageLink struct {
+	ChannelID  string
+	MessageIDs []string
+	// Where the original message lives, so replies to a copy can be traced back
+	SourceChannelID string
+	Direction       string
+	// Webhook messages have to be edited through the webhook
+	Webhook bool
+	// Staff replies sent without the responder's name
+	Anonymous bool
+}
+
+// linkDoc is one forwarded copy in the message_links collection; a link split
+// over several messages is stored as one document per part.
+type linkDoc struct {
+	SourceMsgID     string    `bson:"source_msg_id"`
+	SourceChannelID string    `bson:"source_channel_id"`
+	DestMsgID       string    `bson:"dest_msg_id"`
+	DestChannelID   string    `bson:"dest_channel_id"`
+	Direction       string    `bson:"direction"`
+	Part            int       `bson:"part"`
+	Webhook         bool      `bson:"webhook,omitempty"`
+	Anonymous       bool      `bson:"anonymous,omitempty"`
+	CreatedAt       time.Time `bson:"created_at"`
+}
+
+// linkMessage records where sourceID was forwarded, replacing any earlier link.
+func linkMessage(sourceID string, link messageLink) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	if _, err := LinkCol.DeleteMany(ctx, bson.M{"source_msg_id": sourceID}); err != nil {
+		slog.Error("cannot replace message link", "message_id", sourceID, "err", err)
+		return
+	}
+	docs := make([]linkDoc, len(link.MessageIDs))
+	for i, id := range link.MessageIDs {
+		docs[i] = linkDoc{
+			SourceMsgID: sourceID, SourceChannelID: link.SourceChannelID,
+			DestMsgID: id, DestChannelID: link.ChannelID,
+			Direction: link.Direction, Part: i,
+			Webhook: link.Webhook, Anonymous: link.Anonymous,
+			CreatedAt: time.Now(),
+		}
+	}
+	if len(docs) == 0 { return }
+	if _, err := LinkCol.InsertMany(ctx, docs); err != nil {
+		slog.Error("cannot save message link", "message_id", sourceID, "err", err)
+	}
+}
+
+// linkedMessage looks a link up by its source message.
+func linkedMessage(sourceID string) (messageLink, bool) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := LinkCol.Find(ctx, bson.M{"source_msg_id": sourceID}, options.Find().SetSort(bson.D{{Key: "part", Value: 1}}))
+	if err != nil {
+		slog.Error("cannot look up message link", "message_id", sourceID, "err", err)
+		return messageLink{}, false
+	}
+	var docs []linkDoc
+	if err = cur.All(ctx, &docs); err != nil || len(docs) == 0 { return messageLink{}, false }
+
+	link := messageLink{
+		ChannelID: docs[0].DestChannelID, SourceChannelID: docs[0].SourceChannelID,
+		Direction: docs[0].Direction, Webhook: docs[0].Webhook, Anonymous: docs[0].Anonymous,
+	}
+	for _, d := range docs {
+		link.MessageIDs = append(link.MessageIDs, d.DestMsgID)
+	}
+	return link, true
+}
+
+// linkByDest looks a link up by one of its forwarded copies.
+func linkByDest(destID string) (*linkDoc, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var d linkDoc
+	err := LinkCol.FindOne(ctx, bson.M{"dest_msg_id": destID}).Decode(&d)
+	if errors.Is(err, mongo.ErrNoDocuments) { return nil, nil }
+	if err != nil { return nil, err }
+	return &d, nil
+}
+
+// counterpart returns the other side of messageID, whichever side it is on:
+// the first forwarded copy of a source message, or the source of a copy.
+func counterpart(messageID string) (channelID, otherID string, ok bool) {
+	if l, ok := linkedMessage(messageID); ok {
+		return l.ChannelID, l.MessageIDs[0], true
+	}
+	d, err := linkByDest(messageID)
+	if err != nil {
+		slog.Error("cannot look up message link", "message_id", messageID, "err", err)
+	}
+	if d == nil { return "", "", false }
+	return d.SourceChannelID, d.SourceMsgID, d.SourceChannelID != ""
+}
+
+// unlinkMessage forgets sourceID and returns where it had been forwarded.
+func unlinkMessage(sourceID string) (messageLink, bool) {
+	link, ok := linkedMessage(sourceID)
+	if !ok { return link, false }
+	ctx, cancel := dbCtx()
+	defer cancel()
+	if _, err := LinkCol.DeleteMany(ctx, bson.M{"source_msg_id": sourceID}); err != nil {
+		slog.Error("cannot delete message link", "message_id", sourceID, "err", err)
+	}
+	return link, true
+}
+
+func messageIDs(msgs []*discordgo.Message) []string {
+	ids := make([]string, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	return ids
+}
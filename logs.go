@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const logsPageSize = 10
+
+// cmdLogs shows a user's modmail history, newest first, defaulting to the
+// ticket's user.
+func cmdLogs(c *commandContext) {
+	target := c.userID
+	if len(c.args) > 0 { target = strings.Trim(c.args[0], "<@!>") }
+
+	embed, components, err := logsPage(target, 0)
+	if err != nil {
+		slog.Error("cannot load logs", "user_id", target, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not load logs.")
+		return
+	}
+	sendMessage(c.s, c.m.ChannelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components})
+}
+
+// handleLogsPage flips pages; arg is "<userID>:<page>".
+func handleLogsPage(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !hasPermission(s, i.GuildID, i.Member.User.ID, commandLevels["logs"]) {
+		respondEphemeral(s, i, "⛔ You don't have permission to use that command.")
+		return
+	}
+	userID, pageStr, _ := strings.Cut(arg, ":")
+	page, _ := strconv.Atoi(pageStr)
+
+	embed, components, err := logsPage(userID, page)
+	if err != nil {
+		slog.Error("cannot load logs", "user_id", userID, "err", err)
+		respondEphemeral(s, i, "❌ Could not load logs.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components},
+	})
+}
+
+// logsPage renders one page of a user's log along with its navigation buttons.
+func logsPage(userID string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	total, err := MsgCol.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil { return nil, nil, err }
+	pages := int((total + logsPageSize - 1) / logsPageSize)
+	if pages == 0 { pages = 1 }
+	page = max(0, min(page, pages-1))
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64(page * logsPageSize)).
+		SetLimit(logsPageSize)
+	cur, err := MsgCol.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil { return nil, nil, err }
+	var logs []ModmailLog
+	if err = cur.All(ctx, &logs); err != nil { return nil, nil, err }
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("📜 Logs for %s", userID),
+		Color: 0x95a5a6,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d · %d messages", page+1, pages, total)},
+	}
+	if len(logs) == 0 {
+		embed.Description = "No messages on record."
+	}
+	for _, l := range logs {
+		content := truncate(l.Content, 200)
+		if content == "" { content = "*(no text)*" }
+		if l.HasFile { content += " 📎" }
+		sender := l.Sender
+		if l.EditOf != "" { sender += " (edited)" }
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%s · %s", sender, displayTime(l.Timestamp)),
+			Value: content,
+		})
+	}
+
+	components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "◀ Newer", Style: discordgo.SecondaryButton, Disabled: page == 0,
+			CustomID: fmt.Sprintf("logs:%s:%d", userID, page-1)},
+		discordgo.Button{Label: "Older ▶", Style: discordgo.SecondaryButton, Disabled: page >= pages-1,
+			CustomID: fmt.Sprintf("logs:%s:%d", userID, page+1)},
+	}}}
+	return embed, components, nil
+}
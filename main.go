@@ -3,16 +3,19 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"hash/fnv"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -24,6 +27,70 @@ var (
 	CategoryID = os.Getenv("CATEGORY_ID")
 	MongoURI   = os.Getenv("MONGO_URI")
 	MsgCol     *mongo.Collection
+	TicketCol  *mongo.Collection
+	BlockCol   *mongo.Collection
+	CounterCol *mongo.Collection
+	SnippetCol *mongo.Collection
+
+	SettingsCol *mongo.Collection
+	LinkCol     *mongo.Collection
+	CategoryCol *mongo.Collection
+
+	TicketStatsCol *mongo.Collection
+	QueueCol       *mongo.Collection
+	ReminderCol    *mongo.Collection
+	FilterCol      *mongo.Collection
+	UserNoteCol    *mongo.Collection
+
+	AutoResponderCol *mongo.Collection
+
+	// Closed tickets are moved here when set, otherwise they're deleted
+	ArchiveCategoryID = os.Getenv("ARCHIVE_CATEGORY_ID")
+	// Command prefix for staff commands in ticket channels
+	Prefix = envOr("PREFIX", "!")
+	// Auto-reply sent outside OFFICE_HOURS when nobody has set !away
+	AutoAwayMessage = os.Getenv("AUTO_AWAY_MESSAGE")
+	// Staff can "!reply <user ID> <message>" from here without opening the ticket
+	StaffOpsChannelID = os.Getenv("STAFF_OPS_CHANNEL_ID")
+	// Staff messages starting with this stay in the channel as internal notes
+	NotePrefix = os.Getenv("NOTE_PREFIX")
+	// Whether plain staff messages hide the responder; !reply and !areply override it
+	AnonReplies = os.Getenv("ANON_REPLIES") != "false"
+	// Tell blocked users why nobody answers instead of dropping them silently
+	BlockedNotice = os.Getenv("BLOCKED_NOTICE") == "true"
+	// Transcripts of closed tickets are posted here
+	LogChannelID = os.Getenv("LOG_CHANNEL_ID")
+	// Opens, closes, blocks, moves and claims are recorded here
+	ModLogChannelID = os.Getenv("MOD_LOG_CHANNEL_ID")
+	// Role pinged when a ticket opens, unless the staff_role_id setting
+	// overrides it; PING_STAFF=false turns the ping off
+	StaffRoleID = os.Getenv("STAFF_ROLE_ID")
+	PingStaff   = os.Getenv("PING_STAFF") != "false"
+	OfficeHours = os.Getenv("OFFICE_HOURS")
+	// Idle tickets are warned after AUTO_CLOSE_HOURS and closed
+	// AUTO_CLOSE_GRACE_HOURS after the warning, while auto-close is enabled
+	AutoCloseAfter = envHours("AUTO_CLOSE_HOURS", 0)
+	AutoCloseGrace = envHours("AUTO_CLOSE_GRACE_HOURS", 24*time.Hour)
+	// Resolved tickets close this long after !resolve unless reopened
+	ResolveTimeout = envHours("RESOLVE_TIMEOUT_HOURS", 24*time.Hour)
+	// New users queue once this many tickets are open (0 means no limit)
+	MaxOpenTickets = envInt("MAX_OPEN_TICKETS", 0)
+	// DMs arriving this soon after a close are held back instead of reopening
+	// straight away (0 disables)
+	ReopenCooldown = time.Duration(envInt("REOPEN_COOLDOWN_SECONDS", 0)) * time.Second
+	// Fetched every KEEPALIVE_MINUTES so hosts that sleep idle services,
+	// like Render's free tier, keep the bot running
+	KeepaliveURL      = os.Getenv("KEEPALIVE_URL")
+	KeepaliveInterval = time.Duration(envInt("KEEPALIVE_MINUTES", 5)) * time.Minute
+	// Staff are warned about tickets left unanswered this long (0 disables)
+	SLATarget = time.Duration(envInt("SLA_MINUTES", 0)) * time.Minute
+	// Parent channel for ticket threads when threads are enabled
+	TicketChannelID = os.Getenv("TICKET_CHANNEL_ID")
+	// Tickets spill into overflow categories once a category holds this many
+	// channels; Discord refuses more than 50
+	CategoryLimit = envInt("CATEGORY_LIMIT", 50)
+	// The community guild users belong to, used for member lookups
+	MainGuildID = envOr("MAIN_GUILD_ID", GuildID)
 )
 
 type ModmailLog struct {
@@ -33,142 +100,594 @@ type ModmailLog struct {
 	HasFile   bool          `bson:"has_file"`
 	Timestamp time.Time     `bson:"timestamp"`
 	Sender    string        `bson:"sender"`
+	Anonymous bool          `bson:"anonymous"`
+	// The Discord message this entry records, so its edits can be linked
+	MessageID string `bson:"message_id,omitempty"`
+	// Set on revisions of an edited message, pointing at the original message
+	EditOf string `bson:"edit_of,omitempty"`
+	// On a revision, the entry it replaces: the original or the previous
+	// revision, so following it walks the whole edit chain
+	Revises bson.ObjectID `bson:"revises,omitempty"`
 }
 
 func main() {
-	if Token == "" || GuildID == "" || CategoryID == "" || MongoURI == "" {
-		log.Fatal("Missing environment variables.")
+	setupLogging()
+	if Token == "" || GuildID == "" || MongoURI == "" {
+		fatal("missing environment variables")
+	}
+	if err := features.validate(); err != nil {
+		fatal("invalid feature configuration", "err", err)
+	}
+	slog.Info("features", "enabled", features.enabled())
+	if err := loadConfig(); err != nil {
+		fatal("invalid routing config", "err", err)
+	}
+	if err := loadLang(); err != nil {
+		fatal("invalid language file", "err", err)
 	}
 
-	client, err := mongo.Connect(options.Client().ApplyURI(MongoURI))
+	client, err := mongo.Connect(options.Client().ApplyURI(MongoURI).SetConnectTimeout(dbTimeout).SetServerSelectionTimeout(dbTimeout))
+	if err != nil {
+		fatal("cannot connect to MongoDB", "err", err)
+	}
+	ctx, cancel := dbCtx()
+	err = client.Ping(ctx, nil)
+	cancel()
 	if err != nil {
-		log.Fatal(err)
+		fatal("cannot reach MongoDB", "err", err)
+	}
+	db := client.Database("modmail_db")
+	MsgCol = db.Collection("messages")
+	TicketCol = db.Collection("tickets")
+	BlockCol = db.Collection("blocked_users")
+	CounterCol = db.Collection("counters")
+	SnippetCol = db.Collection("snippets")
+	SettingsCol = db.Collection("settings")
+	LinkCol = db.Collection("message_links")
+	CategoryCol = db.Collection("categories")
+	TicketStatsCol = db.Collection("ticket_stats")
+	QueueCol = db.Collection("ticket_queue")
+	ReminderCol = db.Collection("reminders")
+	FilterCol = db.Collection("content_filters")
+	UserNoteCol = db.Collection("user_notes")
+	AutoResponderCol = db.Collection("autoresponders")
+	ensureIndexes()
+	loadCategoryChains()
+	replayDeadLetters()
+	startLogRetrier()
+	refreshBlocklist()
+	if err := loadFilters(); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load content filters", "err", err)
+	}
+	if err := loadAutoResponders(); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load autoresponders", "err", err)
+	}
+	if err := loadSettings(); err != nil {
+		slog.Warn("cannot load settings, using defaults", "err", err)
 	}
-	MsgCol = client.Database("modmail_db").Collection("messages")
 
 	dg, err := discordgo.New("Bot " + Token)
 	if err != nil {
-		log.Fatal(err)
+		fatal("cannot create Discord session", "err", err)
 	}
 
-	dg.Identify.Intents = discordgo.IntentDirectMessages | discordgo.IntentGuildMessages | discordgo.IntentMessageContent | discordgo.IntentGuilds
-	dg.AddHandler(messageCreate)
+	dg.Identify.Intents = discordgo.IntentDirectMessages | discordgo.IntentGuildMessages | discordgo.IntentMessageContent | discordgo.IntentGuilds | discordgo.IntentGuildMessageTyping | discordgo.IntentGuildMessageReactions
+	removeHandlers := []func(){
+		dg.AddHandler(messageCreate),
+		dg.AddHandler(interactionCreate),
+		dg.AddHandler(messageUpdate),
+		dg.AddHandler(messageDelete),
+		dg.AddHandler(messageDeleteBulk),
+		dg.AddHandler(typingStart),
+		dg.AddHandler(onConnect),
+		dg.AddHandler(onDisconnect),
+		dg.AddHandler(onResumed),
+		dg.AddHandler(channelCreate),
+		dg.AddHandler(channelUpdate),
+		dg.AddHandler(channelDelete),
+		dg.AddHandler(messageReactionAdd),
+		dg.AddHandler(guildDelete),
+		dg.AddHandler(guildCreate),
+	}
 
+	startForwarders()
 	if err = dg.Open(); err != nil {
-		log.Fatal(err)
+		fatal("cannot open Discord gateway", "err", err)
 	}
+	if err := checkTargets(dg); err != nil {
+		dg.Close()
+		fatal("invalid ticket category", "err", err)
+	}
+	registerCommands(dg)
+	startAutoCloser(dg)
+	go reconcileDuplicates(dg)
+	loadReminders(dg)
 
+	port := os.Getenv("PORT")
+	if port == "" { port = "10000" }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleRoot)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: ":" + port, Handler: mux}
 	go func() {
-		port := os.Getenv("PORT")
-		if port == "" { port = "10000" }
-		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { fmt.Fprintf(w, "Modmail Bot Active") })
-		http.ListenAndServe(":"+port, nil)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("HTTP server error", "err", err)
+		}
 	}()
+	startKeepalive()
 
-	fmt.Println("Bot is live. Ticket creation alerts and reactions enabled.")
+	slog.Info("bot is live", "user", dg.State.User.Username, "guilds", staffGuilds())
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-stop
+
+	slog.Info("shutting down: removing event handlers")
+	for _, remove := range removeHandlers {
+		remove()
+	}
+	slog.Info("shutting down: draining forward queue")
+	stopForwarders()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slog.Info("shutting down: stopping HTTP server")
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("HTTP server shutdown", "err", err)
+	}
+
+	slog.Info("shutting down: closing Discord gateway")
+	if err := dg.Close(); err != nil {
+		slog.Error("Discord close", "err", err)
+	}
+
+	flushDeadLetters()
+	slog.Info("shutting down: disconnecting MongoDB")
+	if err := client.Disconnect(ctx); err != nil {
+		slog.Error("MongoDB disconnect", "err", err)
+	}
+	slog.Info("shutdown complete")
 }
 
 func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if m.Author.ID == s.State.User.ID { return }
+	// Our own webhook posts come back here too; forwarding them again would
+	// echo the user's messages back to them
+	if m.Author.ID == s.State.User.ID || m.Author.Bot || m.WebhookID != "" { return }
 
 	// 1. USER -> STAFF (Incoming DM)
 	if m.GuildID == "" {
-		reg, _ := regexp.Compile("[^a-zA-Z0-9]+")
-		cleanName := strings.ToLower(reg.ReplaceAllString(m.Author.Username, ""))
-		channelName := fmt.Sprintf("ticket-%s", cleanName)
-
-		channels, _ := s.GuildChannels(GuildID)
-		var targetChannel *discordgo.Channel
-		for _, ch := range channels {
-			if strings.Contains(ch.Topic, m.Author.ID) {
-				targetChannel = ch
-				break
+		if isBlocked(m.Author.ID) {
+			slog.Debug("dropping message from blocked user", "user_id", m.Author.ID)
+			if BlockedNotice {
+				sendText(s, m.ChannelID, tr("blocked"))
 			}
+			return
 		}
 
-		// First-time ticket creation logic
-		if targetChannel == nil {
-			targetChannel, _ = s.GuildChannelCreateComplex(GuildID, discordgo.GuildChannelCreateData{
-				Name: channelName, Type: discordgo.ChannelTypeGuildText, ParentID: CategoryID, Topic: "Modmail ID: " + m.Author.ID,
-			})
-			
-			// Notify User of creation
-			s.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
-				Title: "🎫 Ticket Created",
-				Description: "Your message has been sent to the staff. Please wait for a response.",
-				Color: 0x2ecc71,
-				Timestamp: time.Now().Format(time.RFC3339),
-			})
-
-			// Notify Staff in new channel
-			s.ChannelMessageSendEmbed(targetChannel.ID, &discordgo.MessageEmbed{
-				Title: "🆕 New Ticket", Description: "User: " + m.Author.Mention(), Color: 0x3498db,
-			})
+		if ok, warn := msgLimiter.allow(m.Author.ID); !ok {
+			if warn {
+				sendText(s, m.ChannelID, tr("slow_down"))
+			}
+			return
 		}
 
-		// Forward message to staff channel
-		embed := &discordgo.MessageEmbed{
-			Author: &discordgo.MessageEmbedAuthor{Name: m.Author.Username, IconURL: m.Author.AvatarURL("")},
-			Description: m.Content,
-			Color: 0x2ecc71,
+		enqueueForward(m.Author.ID, func() { handleUserDM(s, m) })
+		return
+	}
+
+	// 2. STAFF -> USER
+	userID := ticketUser(s, m.ChannelID)
+	if userID == "" {
+		if isStaffGuild(m.GuildID) || (StaffOpsChannelID != "" && m.ChannelID == StaffOpsChannelID) { dispatchOpsCommand(s, m) }
+		return
+	}
+
+	if dispatchCommand(s, m, userID) { return }
+	// Internal notes are never forwarded
+	if NotePrefix != "" && strings.HasPrefix(m.Content, NotePrefix) {
+		saveNote(s, m, userID, strings.TrimSpace(strings.TrimPrefix(m.Content, NotePrefix)))
+		return
+	}
+	if isSideChatter(m.Content) { return }
+
+	content, author := m.Content, m.Author
+	if AnonReplies { author = nil }
+	enqueueForward(userID, func() { forwardStaffMessage(s, m, userID, content, author) })
+}
+
+// handleUserDM finds or opens the user's ticket and forwards their DM to it.
+// It runs on a forwarding worker.
+func handleUserDM(s *discordgo.Session, m *discordgo.MessageCreate) {
+	unlock := lockUser(m.Author.ID)
+	defer unlock()
+	routeUserDM(s, m)
+}
+
+// routeUserDM forwards m to its author's ticket, opening one if every check
+// for a new ticket passes. Callers hold the user's lock.
+func routeUserDM(s *discordgo.Session, m *discordgo.MessageCreate) {
+	targetChannel := ticketChannel(s, m.Author.ID)
+
+	// First-time ticket creation logic
+	if targetChannel == nil {
+		// Still waiting for the user to pick a category
+		if queuePending(m) { return }
+		if !checkMembership(s, m) { return }
+		if holdForCooldown(s, m) { return }
+		if autoRespond(s, m) { return }
+
+		if ok, warn := ticketLimiter.allow(m.Author.ID); !ok {
+			if warn {
+				sendText(s, m.ChannelID, tr("too_many_tickets"))
+			}
+			return
 		}
-		if len(m.Attachments) > 0 { embed.Image = &discordgo.MessageEmbedImage{URL: m.Attachments[0].URL} }
 
-		staffMsg, err := s.ChannelMessageSendEmbed(targetChannel.ID, embed)
-		if err == nil {
-			// React to the message in the staff channel to show it arrived
-			s.MessageReactionAdd(targetChannel.ID, staffMsg.ID, "📩")
+		if queueForCapacity(s, m) { return }
+		if promptCategory(s, m) { return }
+		if targetChannel = openTicket(s, m.Author, m.ChannelID, resolveTarget(m.Author.ID), nil); targetChannel == nil {
+			undelivered(s, m)
+			return
 		}
-		
-		logToDB(m.Author.ID, m.Content, "user", len(m.Attachments) > 0)
-		return
 	}
 
-	// 2. STAFF -> USER
-	ch, err := s.State.Channel(m.ChannelID)
+	forwardUserMessage(s, m, targetChannel)
+	sendAwayNotice(s, m)
+}
+
+// openTicket creates the ticket channel in target, records it and lets both
+// the user and staff know. It returns nil if the channel can't be created.
+// A non-nil openedBy marks the ticket as started by that staff member, who has
+// already messaged the user, so the welcome and staff ping are skipped.
+func openTicket(s *discordgo.Session, user *discordgo.User, dmChannelID string, target Target, openedBy *discordgo.User) *discordgo.Channel {
+	number, err := nextTicketNumber()
 	if err != nil {
-		ch, _ = s.Channel(m.ChannelID)
+		slog.Error("cannot allocate ticket number", "user_id", user.ID, "err", err)
 	}
-	if ch == nil || ch.ParentID != CategoryID || !strings.HasPrefix(ch.Name, "ticket-") {
-		return
+	channelName := ticketChannelName(number, user)
+
+	ch, err := createTicketChannel(s, target, channelName, user.ID)
+	if err != nil {
+		slog.Error("cannot create ticket channel", "user_id", user.ID, "guild_id", target.GuildID, "category_id", target.CategoryID, "err", err)
+		alertAdmins(s, fmt.Sprintf("⚠️ Cannot open a ticket for <@%s> in **%s**: %v", user.ID, target.Name, err))
+		return nil
+	}
+	t := Ticket{Number: number, UserID: user.ID, GuildID: ch.GuildID, ChannelID: ch.ID, Category: target.Name}
+	if openedBy != nil { t.OpenedBy = openedBy.ID }
+	err = saveTicket(t)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another process won the race; drop our channel and use theirs
+		slog.Warn("ticket already exists, discarding duplicate channel", "user_id", user.ID, "channel_id", ch.ID)
+		s.ChannelDelete(ch.ID)
+		if t, _ := findOpenTicket(user.ID); t != nil { return fetchChannel(s, t.ChannelID) }
+		return nil
+	} else if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot save ticket", "user_id", user.ID, "channel_id", ch.ID, "err", err)
 	}
 
-	userID := ""
-	if strings.HasPrefix(ch.Topic, "Modmail ID: ") {
-		userID = strings.TrimPrefix(ch.Topic, "Modmail ID: ")
+	ticketsOpened.Inc()
+	slog.Info("ticket opened", "user_id", user.ID, "channel_id", ch.ID, "ticket", number, "category", target.Name, "opened_by", t.OpenedBy)
+	logAction(s, modEvent{Action: "open", Actor: openedBy, UserID: user.ID, ChannelID: ch.ID,
+		Detail: fmt.Sprintf("Ticket #%d in **%s**", number, target.Name)})
+
+	embeds := []*discordgo.MessageEmbed{newTicketEmbed(number, user), buildUserInfoEmbed(s, user, fetchMember(s, user.ID))}
+	addUserNotes(embeds[0], user.ID)
+	applyAutoTag(s, user.ID, ch)
+	var intro *discordgo.Message
+	if openedBy != nil {
+		embeds[0].Description += "\nOpened by " + openedBy.Mention()
+		intro, _ = sendMessage(s, ch.ID, &discordgo.MessageSend{Embeds: embeds, AllowedMentions: noMentions})
+	} else {
+		// Notify User of creation, then staff
+		if welcome := sendWelcome(s, dmChannelID, t); welcome != nil {
+			if err := setTicketFields(ch.ID, bson.M{"user_status_msg_id": welcome.ID}); err != nil {
+				dbErrors.Inc()
+				slog.Error("cannot record welcome message", "channel_id", ch.ID, "err", err)
+			}
+		}
+		intro = notifyNewTicket(s, ch.ID, embeds...)
 	}
-	if userID == "" { return }
+	if intro != nil { addCloseReaction(s, intro) }
+	postHistorySummary(s, ch.ID, user.ID)
+	return ch
+}
 
-	if strings.ToLower(m.Content) == "!close" {
-		s.ChannelDelete(m.ChannelID)
-		dm, _ := s.UserChannelCreate(userID)
-		s.ChannelMessageSend(dm.ID, "🔒 Your ticket has been closed.")
-		return
+var nameCleaner = regexp.MustCompile("[^a-z0-9]+")
+
+// maxChannelName leaves room under Discord's 100-character limit for a
+// priority prefix.
+const maxChannelName = 96
+
+// ticketChannelName builds "ticket-<number>-<name>" from the username, falling
+// back to the user ID for names with nothing usable in them. The number keeps
+// names unique; if it couldn't be allocated, a hash of the user ID stands in.
+func ticketChannelName(number int64, user *discordgo.User) string {
+	name := nameCleaner.ReplaceAllString(strings.ToLower(user.Username), "")
+	if name == "" { name = user.ID }
+	prefix := ticketNamePrefix(number, user.ID)
+	return prefix + truncateBytes(name, maxChannelName-len(prefix))
+}
+
+// ticketNamePrefix is the "ticket-<number>-" every ticket channel name starts with.
+func ticketNamePrefix(number int64, userID string) string {
+	id := fmt.Sprintf("%04d", number)
+	if number == 0 {
+		h := fnv.New32a()
+		h.Write([]byte(userID))
+		id = fmt.Sprintf("%08x", h.Sum32())
 	}
+	return "ticket-" + id + "-"
+}
 
-	// Forward to user
-	dm, err := s.UserChannelCreate(userID)
-	if err != nil { return }
+// truncateBytes cuts an ASCII string to at most n bytes.
+func truncateBytes(s string, n int) string {
+	if len(s) <= n { return s }
+	return s[:n]
+}
 
-	embed := &discordgo.MessageEmbed{
-		Title: "💬 Staff Response", Description: m.Content, Color: 0x3498db,
+// forwardUserMessage posts a user's DM into their ticket channel.
+func forwardUserMessage(s *discordgo.Session, m *discordgo.MessageCreate, ch *discordgo.Channel) {
+	sendTyping(s, ch.ID)
+	text, files := withStickers(m.Content, m.Attachments, m.StickerItems)
+	for _, a := range files {
+		if reason := validateAttachment(a); reason != "" {
+			slog.Warn("withholding attachment", "user_id", m.Author.ID, "file", a.Filename, "size", a.Size, "reason", reason)
+		}
+	}
+	shown, filtered := redact(text)
+	content := replyQuote(s, m.Message) + shown + linkPreviews(s, shown)
+	link, err := postAs(s, ch.ID, m.Author.Username, m.Author.AvatarURL(""), content, files,
+		userEmbeds(m.Author, content, files))
+	link.SourceChannelID, link.Direction = m.ChannelID, toStaff
+	if len(link.MessageIDs) > 0 { linkMessage(m.ID, link) }
+	if err != nil {
+		slog.Error("cannot forward user message", "user_id", m.Author.ID, "channel_id", ch.ID, "err", err)
+	} else {
+		slog.Debug("forwarded user message", "user_id", m.Author.ID, "channel_id", ch.ID, "message_id", m.ID)
+		messagesForwarded.WithLabelValues("user_to_staff").Inc()
+		// React to the message in the staff channel to show it arrived
+		react(s, ch.ID, link.MessageIDs[len(link.MessageIDs)-1], settings().ReceivedEmoji)
+		// and let the user know it got through
+		if features.DMReceipts { react(s, m.ChannelID, m.ID, settings().SentEmoji) }
 	}
-	if len(m.Attachments) > 0 { embed.Image = &discordgo.MessageEmbedImage{URL: m.Attachments[0].URL} }
 
-	_, err = s.ChannelMessageSendEmbed(dm.ID, embed)
+	reuploadFiles(s, ch.ID, m.Attachments)
+	if filtered && FlagFiltered { sendText(s, ch.ID, "🚩 Part of this message was filtered. The original is in the logs.") }
+
+	// The log keeps the original for audits
+	saveLog(ModmailLog{UserID: m.Author.ID, Content: text, Sender: "user", HasFile: len(files) > 0, MessageID: m.ID})
+	fireAlerts(s, ch.ID)
+}
+
+// forwardStaffMessage relays a message from a ticket channel to the user and
+// reacts to show whether it was delivered. Nothing is sent while relaying is
+// paused for the ticket.
+func forwardStaffMessage(s *discordgo.Session, m *discordgo.MessageCreate, userID, content string, author *discordgo.User) {
+	t, err := findTicketByChannel(m.ChannelID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "channel_id", m.ChannelID, "err", err)
+	}
+	if t != nil && t.NoRelay {
+		// Plain messages are side-chatter; a reply command meant to be sent
+		if strings.HasPrefix(m.Content, Prefix) {
+			react(s, m.ChannelID, m.ID, "🔇")
+			sendText(s, m.ChannelID, "Relaying is paused in this ticket, your message was not sent. Use `"+Prefix+"relay on` first.")
+		}
+		return
+	}
+	if t != nil && t.ClaimedBy != "" && t.ClaimedBy != m.Author.ID {
+		react(s, m.ChannelID, m.ID, "⚠️")
+		sendText(s, m.ChannelID, "This ticket is claimed by <@"+t.ClaimedBy+">, your message was not sent.")
+		return
+	}
+
+	// Forward to user, quoting whatever the staff member replied to
+	link, err := relayToUser(s, userID, m.ID, replyQuote(s, m.Message)+content, m.Attachments, author)
 	if err == nil {
+		link.SourceChannelID, link.Direction = m.ChannelID, toUser
+		linkMessage(m.ID, link)
+		recordFirstResponse(m.ChannelID)
+		slog.Debug("forwarded staff message", "user_id", userID, "channel_id", m.ChannelID, "message_id", m.ID)
 		// React to the staff's message to confirm it was sent to the user
-		s.MessageReactionAdd(m.ChannelID, m.ID, "✅")
-		logToDB(userID, m.Content, "staff", len(m.Attachments) > 0)
-	} else {
-		s.ChannelMessageSend(m.ChannelID, "❌ Failed to send DM (DMs might be closed).")
+		react(s, m.ChannelID, m.ID, settings().SentEmoji)
+		return
 	}
+	react(s, m.ChannelID, m.ID, "❌")
+	sendText(s, m.ChannelID, deliveryFailure(m.ChannelID, userID, err))
+}
+
+// deliveryFailure explains to staff why a reply didn't reach the user. Users
+// who can't receive DMs at all get their ticket flagged as undeliverable.
+func deliveryFailure(channelID, userID string, err error) string {
+	dmFailures.Inc()
+	if isCannotDM(err) {
+		if channelID != "" { markTicketUndeliverable(channelID) }
+		return "❌ <@" + userID + "> has DMs disabled or has blocked the bot, so they can't receive replies."
+	}
+	slog.Warn("cannot DM user", "user_id", userID, "channel_id", channelID, "err", err)
+	return "❌ Failed to send DM, please try again."
+}
+
+// ticketUser returns the ID of the user a ticket channel belongs to, or "" if
+// the channel is not a modmail ticket.
+func ticketUser(s *discordgo.Session, channelID string) string {
+	ch := fetchChannel(s, channelID)
+	if ch != nil && ch.IsThread() {
+		// Threads have no topic, so the ticket record is the only link to the user
+		if ch.ParentID != TicketChannelID { return "" }
+		if t, _ := findTicketByChannel(channelID); t != nil { return t.UserID }
+		return ""
+	}
+	if ch == nil || !isTicketCategory(ch.ParentID) || !strings.HasPrefix(stripPriority(ch.Name), "ticket-") {
+		return ""
+	}
+	if !strings.HasPrefix(ch.Topic, "Modmail ID: ") { return "" }
+	// Tags follow on the next line
+	userID, _, _ := strings.Cut(strings.TrimPrefix(ch.Topic, "Modmail ID: "), "\n")
+	return userID
+}
+
+// fetchChannel looks a channel up in the state cache, falling back to the API.
+func fetchChannel(s *discordgo.Session, channelID string) *discordgo.Channel {
+	ch, err := s.State.Channel(channelID)
+	if err != nil {
+		ch, _ = s.Channel(channelID)
+	}
+	return ch
+}
+
+// alertAdmins posts a problem staff must fix to LOG_CHANNEL_ID.
+func alertAdmins(s *discordgo.Session, msg string) {
+	if LogChannelID == "" { return }
+	if _, err := sendText(s, LogChannelID, msg); err != nil {
+		slog.Error("cannot post admin alert", "channel_id", LogChannelID, "err", err)
+	}
+}
+
+// undelivered handles a DM that couldn't open a ticket: the message is posted
+// to LOG_CHANNEL_ID so staff still see it, and the user is told to try later.
+func undelivered(s *discordgo.Session, m *discordgo.MessageCreate) {
+	react(s, m.ChannelID, m.ID, "⚠️")
+	sendText(s, m.ChannelID, tr("undelivered"))
+	if LogChannelID == "" { return }
+	if _, err := sendEmbeds(s, LogChannelID, userEmbeds(m.Author, m.Content, m.Attachments)); err != nil {
+		slog.Error("cannot post undelivered message", "user_id", m.Author.ID, "channel_id", LogChannelID, "err", err)
+	}
+}
+
+// closeTicket archives (or deletes) the ticket channel and lets the user know,
+// including the reason if one was given. A nil closer means the bot closed it.
+func closeTicket(s *discordgo.Session, channelID, userID string, closer *discordgo.User, reason string, silent bool) {
+	closedBy, closedByName := "", "the system"
+	if closer != nil { closedBy, closedByName = closer.ID, closer.Username }
+	note := "Ticket closed by " + closedByName
+	if silent { note = "Ticket closed silently by " + closedByName }
+	if reason != "" { note += ": " + reason }
+	logToDB(userID, note, "system", false)
+
+	t, _ := findTicketByChannel(channelID)
+	if t == nil { t = &Ticket{UserID: userID, ChannelID: channelID} }
+	t.Silent, t.ClosedBy, t.CloseReason = silent, closedBy, reason
+	postTranscript(s, *t)
+
+	if silent {
+		if err := setTicketFields(channelID, bson.M{"silent": true}); err != nil {
+			slog.Error("cannot mark ticket silent", "channel_id", channelID, "err", err)
+		}
+	}
+	markTicketClosed(channelID, closedBy, reason)
+	recordTicketStat(*t, closedBy)
+	cancelReminders(channelID)
+	ticketsClosed.Inc()
+	logAction(s, modEvent{Action: "close", Actor: closer, UserID: userID, ChannelID: channelID, Detail: note})
+	go promoteQueued(s)
+	archiveTicketChannel(s, channelID)
+	if silent { return }
+	if dm, err := openDM(s, userID); err == nil {
+		msg := tr("ticket_closed")
+		if reason != "" { msg = tr("ticket_closed_reason", reason) }
+		sendText(s, dm.ID, msg)
+	}
+}
+
+// userEmbeds renders a user's DM for the staff channel.
+func userEmbeds(author *discordgo.User, content string, files []*discordgo.MessageAttachment) []*discordgo.MessageEmbed {
+	return chunkEmbeds(discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{Name: author.Username, IconURL: author.AvatarURL("")},
+		Color: settings().UserColor,
+	}, content, files)
+}
+
+// staffEmbeds renders a staff reply. A nil author keeps the reply anonymous.
+func staffEmbeds(content string, files []*discordgo.MessageAttachment, author *discordgo.User) []*discordgo.MessageEmbed {
+	st := settings()
+	tmpl := discordgo.MessageEmbed{Title: st.StaffTitle, Color: st.StaffColor}
+	if author != nil {
+		tmpl.Author = &discordgo.MessageEmbedAuthor{Name: author.Username, IconURL: author.AvatarURL("")}
+	}
+	return chunkEmbeds(tmpl, content, files)
+}
+
+// relayToUser DMs a staff reply to the ticket user and returns where it
+// landed. A nil author keeps the reply anonymous. sourceID is the staff
+// message being relayed, if there is one.
+func relayToUser(s *discordgo.Session, userID, sourceID, content string, files []*discordgo.MessageAttachment, author *discordgo.User) (messageLink, error) {
+	dm, err := openDM(s, userID)
+	if err != nil { return messageLink{}, err }
+	sendTyping(s, dm.ID)
+
+	// Staff guild CDN links may not open for the user, so files are uploaded
+	// into the DM and only what can't be uploaded is linked
+	uploads, links := downloadFiles(files)
+	sent, err := sendDM(s, dm.ID, staffEmbeds(portableEmoji(s, content), links, author), uploads...)
+	if err != nil { return messageLink{}, err }
+	messagesForwarded.WithLabelValues("staff_to_user").Inc()
+	saveLog(ModmailLog{UserID: userID, Content: content, Sender: "staff", HasFile: len(files) > 0, Anonymous: author == nil, MessageID: sourceID})
+	return messageLink{ChannelID: dm.ID, MessageIDs: messageIDs(sent), Anonymous: author == nil}, nil
+}
+
+// setupLogging installs a text slog handler at LOG_LEVEL (debug, info, warn or
+// error; info by default).
+func setupLogging() {
+	var level slog.Level
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := level.UnmarshalText([]byte(v)); err != nil {
+			defer slog.Warn("ignoring invalid LOG_LEVEL", "value", v)
+		}
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+}
+
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" { return v }
+	return def
+}
+
+// envInt reads a whole number from the environment.
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" { return def }
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		slog.Warn("ignoring invalid setting", "key", key, "value", v)
+		return def
+	}
+	return n
+}
+
+// envHours reads a whole number of hours from the environment.
+func envHours(key string, def time.Duration) time.Duration {
+	return time.Duration(envInt(key, int(def/time.Hour))) * time.Hour
 }
 
 func logToDB(uid, content, sender string, hasFile bool) {
-	entry := ModmailLog{UserID: uid, Content: content, Timestamp: time.Now(), Sender: sender, HasFile: hasFile}
-	_, _ = MsgCol.InsertOne(context.Background(), entry)
+	saveLog(ModmailLog{UserID: uid, Content: content, Sender: sender, HasFile: hasFile})
+}
+
+// saveLog records entry, queueing it for retry if MongoDB is unavailable.
+func saveLog(entry ModmailLog) {
+	entry.ID, entry.Timestamp = bson.NewObjectID(), time.Now()
+	if err := insertLog(entry); err != nil {
+		dbErrors.Inc()
+		slog.Warn("cannot log message, queued for retry", "user_id", entry.UserID, "sender", entry.Sender, "err", err)
+		queueFailedLog(entry)
+	}
+}
+
+// dbTimeout bounds every MongoDB operation so a hung server can't wedge handlers.
+const dbTimeout = 5 * time.Second
+
+func dbCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), dbTimeout)
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTicketChannelName(t *testing.T) {
+	tests := []struct {
+		name     string
+		number   int64
+		username string
+		want     string
+	}{
+		{"plain", 12, "Alice", "ticket-0012-alice"},
+		{"symbols dropped", 3, "b.o_b!", "ticket-0003-bob"},
+		{"emoji only falls back to ID", 7, "🎉🎉", "ticket-0007-111"},
+		{"whitespace only falls back to ID", 8, "   ", "ticket-0008-111"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ticketChannelName(tt.number, &discordgo.User{ID: "111", Username: tt.username})
+			if got != tt.want {
+				t.Errorf("ticketChannelName(%d, %q) = %q, want %q", tt.number, tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTicketChannelNameTooLong(t *testing.T) {
+	got := ticketChannelName(1, &discordgo.User{ID: "111", Username: strings.Repeat("a", 150)})
+	if len(got) > 100 {
+		t.Errorf("name is %d characters, Discord allows 100", len(got))
+	}
+	if !strings.HasPrefix(got, "ticket-0001-aaa") {
+		t.Errorf("got %q, want the prefix and the start of the name", got)
+	}
+}
+
+// Usernames that clean to the same string still get distinct channels, by
+// ticket number or, without one, by a hash of the user ID.
+func TestTicketChannelNameCollisions(t *testing.T) {
+	a := &discordgo.User{ID: "111", Username: "Bob!"}
+	b := &discordgo.User{ID: "222", Username: "bob"}
+	if x, y := ticketChannelName(1, a), ticketChannelName(2, b); x == y {
+		t.Errorf("numbered tickets share the name %q", x)
+	}
+	if x, y := ticketChannelName(0, a), ticketChannelName(0, b); x == y {
+		t.Errorf("unnumbered tickets share the name %q", x)
+	}
+}
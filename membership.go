@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// membershipTTL is how long a membership lookup is trusted.
+const membershipTTL = 5 * time.Minute
+
+type membershipResult struct {
+	member    bool
+	checkedAt time.Time
+}
+
+var memberships = struct {
+	sync.Mutex
+	byUser map[string]membershipResult
+}{byUser: map[string]membershipResult{}}
+
+// checkMembership reports whether m's author may open a ticket under
+// the membership requirement, telling them why not when they can't.
+func checkMembership(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if !features.RequireMembership { return true }
+	if isGuildMember(s, m.Author.ID) { return true }
+	slog.Debug("rejecting ticket from non-member", "user_id", m.Author.ID)
+	sendText(s, m.ChannelID, tr("not_member", mainGuildName(s)))
+	return false
+}
+
+// isGuildMember looks userID up in MAIN_GUILD_ID. If the lookup itself isn't
+// possible, because the bot isn't in the guild or lacks access, everyone is
+// let through rather than locking all users out.
+func isGuildMember(s *discordgo.Session, userID string) bool {
+	memberships.Lock()
+	r, ok := memberships.byUser[userID]
+	memberships.Unlock()
+	if ok && time.Since(r.checkedAt) < membershipTTL { return r.member }
+	if !guildAvailable(MainGuildID) { return true }
+
+	member := true
+	if _, err := s.State.Member(MainGuildID, userID); err != nil {
+		_, err = s.GuildMember(MainGuildID, userID)
+		var rerr *discordgo.RESTError
+		switch {
+		case err == nil:
+		case errors.As(err, &rerr) && rerr.Message != nil &&
+			(rerr.Message.Code == discordgo.ErrCodeUnknownMember || rerr.Message.Code == discordgo.ErrCodeUnknownUser):
+			member = false
+		default:
+			slog.Warn("cannot check guild membership, allowing user", "user_id", userID, "guild_id", MainGuildID, "err", err)
+			return true
+		}
+	}
+
+	memberships.Lock()
+	defer memberships.Unlock()
+	if len(memberships.byUser) > 1024 {
+		for id, r := range memberships.byUser {
+			if time.Since(r.checkedAt) >= membershipTTL { delete(memberships.byUser, id) }
+		}
+	}
+	memberships.byUser[userID] = membershipResult{member: member, checkedAt: time.Now()}
+	return member
+}
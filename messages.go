@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dmRetries is how many times a DM is retried after a rate limit or server
+// error.
+const dmRetries = 2
+
+// Discord rejects embeds whose description is longer than this.
+const maxEmbedDesc = 4096
+
+// splitContent breaks content into chunks that fit in an embed description.
+func splitContent(content string) []string {
+	return splitLimit(content, maxEmbedDesc)
+}
+
+// splitLimit breaks s into pieces of at most limit characters, cutting on the
+// last newline or space before the limit where possible. Text without any
+// break points is hard-split. An empty string yields a single empty chunk.
+func splitLimit(s string, limit int) []string {
+	var chunks []string
+	r := []rune(s)
+	for len(r) > limit {
+		cut, skip := limit, 0
+		if i := lastIndexRune(r[:limit+1], '\n'); i > 0 {
+			cut, skip = i, 1
+		} else if i := lastIndexRune(r[:limit+1], ' '); i > 0 {
+			cut, skip = i, 1
+		}
+		chunks = append(chunks, string(r[:cut]))
+		r = r[cut+skip:]
+	}
+	if len(r) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, string(r))
+	}
+	return chunks
+}
+
+func lastIndexRune(r []rune, c rune) int {
+	for i := len(r) - 1; i >= 0; i-- {
+		if r[i] == c { return i }
+	}
+	return -1
+}
+
+// noMentions is sent with anything that may carry user-written text, so
+// nothing in it can ping. Sends that mean to ping build their own.
+var noMentions = &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}}
+
+// massMentions breaks up @everyone and @here with a zero-width space, so text
+// stays harmless even if it is copied into a message that allows mentions.
+var massMentions = strings.NewReplacer("@everyone", "@\u200beveryone", "@here", "@\u200bhere")
+
+// sendText sends content as plain messages, split on newlines or spaces to fit
+// Discord's message length limit. It stops at the first failure. Mentions in
+// content never ping.
+func sendText(s *discordgo.Session, channelID, content string) ([]*discordgo.Message, error) {
+	var sent []*discordgo.Message
+	for _, chunk := range splitLimit(massMentions.Replace(content), maxMessageLen) {
+		var msg *discordgo.Message
+		err := withRetry(func() (err error) {
+			msg, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{Content: chunk, AllowedMentions: noMentions}, manualRateLimit)
+			return err
+		}, apiAttempts)
+		if err != nil { return sent, err }
+		sent = append(sent, msg)
+	}
+	return sent, nil
+}
+
+// chunkEmbeds renders content and attachments into as many copies of tmpl as
+// needed to respect the description limit. The title, author and image only
+// appear on the first embed. Further images follow it as image-only embeds
+// sharing its URL, which Discord shows as one gallery; groupEmbeds keeps them
+// in the same message.
+func chunkEmbeds(tmpl discordgo.MessageEmbed, content string, files []*discordgo.MessageAttachment) []*discordgo.MessageEmbed {
+	full := &discordgo.MessageEmbed{Description: content}
+	gallery := attachFiles(full, files)
+
+	var embeds []*discordgo.MessageEmbed
+	for i, chunk := range splitContent(full.Description) {
+		e := tmpl
+		e.Description = chunk
+		if i > 0 {
+			e.Title, e.Author = "", nil
+			embeds = append(embeds, &e)
+			continue
+		}
+		e.Image = full.Image
+		embeds = append(embeds, &e)
+		if len(gallery) == 0 { continue }
+		e.URL = full.Image.URL
+		for _, url := range gallery {
+			embeds = append(embeds, &discordgo.MessageEmbed{URL: e.URL, Image: &discordgo.MessageEmbedImage{URL: url}})
+		}
+	}
+	return embeds
+}
+
+// groupEmbeds splits embeds into the sets sent as one message each: an embed
+// on its own, or together with the gallery images that follow it.
+func groupEmbeds(embeds []*discordgo.MessageEmbed) [][]*discordgo.MessageEmbed {
+	var groups [][]*discordgo.MessageEmbed
+	for i, e := range embeds {
+		if i > 0 && e.URL != "" && e.URL == embeds[i-1].URL {
+			groups[len(groups)-1] = append(groups[len(groups)-1], e)
+			continue
+		}
+		groups = append(groups, []*discordgo.MessageEmbed{e})
+	}
+	return groups
+}
+
+// sendEmbed sends a single embed with mentions disabled.
+func sendEmbed(s *discordgo.Session, channelID string, e *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	return sendEmbedGroup(s, channelID, []*discordgo.MessageEmbed{e})
+}
+
+// sendEmbedGroup sends embeds together in one message with mentions disabled.
+func sendEmbedGroup(s *discordgo.Session, channelID string, embeds []*discordgo.MessageEmbed) (msg *discordgo.Message, err error) {
+	err = withRetry(func() error {
+		msg, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{Embeds: embeds, AllowedMentions: noMentions}, manualRateLimit)
+		return err
+	}, apiAttempts)
+	return msg, err
+}
+
+// sendMessage sends a message built by the caller, who picks its mentions.
+func sendMessage(s *discordgo.Session, channelID string, send *discordgo.MessageSend) (msg *discordgo.Message, err error) {
+	err = withRetry(func() error {
+		// A failed attempt may have read part of the files
+		for _, f := range send.Files {
+			if r, ok := f.Reader.(io.Seeker); ok { r.Seek(0, io.SeekStart) }
+		}
+		msg, err = s.ChannelMessageSendComplex(channelID, send, manualRateLimit)
+		return err
+	}, apiAttempts)
+	return msg, err
+}
+
+// openDM returns the DM channel with userID.
+func openDM(s *discordgo.Session, userID string) (ch *discordgo.Channel, err error) {
+	err = withRetry(func() error {
+		ch, err = s.UserChannelCreate(userID, manualRateLimit)
+		return err
+	}, apiAttempts)
+	return ch, err
+}
+
+// sendEmbeds sends the embeds in order, a message per embed or gallery, and
+// stops at the first failure. Like every send here, rate limits and server
+// errors are retried first.
+func sendEmbeds(s *discordgo.Session, channelID string, embeds []*discordgo.MessageEmbed) ([]*discordgo.Message, error) {
+	var sent []*discordgo.Message
+	for _, group := range groupEmbeds(embeds) {
+		msg, err := sendEmbedGroup(s, channelID, group)
+		if err != nil { return sent, err }
+		sent = append(sent, msg)
+	}
+	return sent, nil
+}
+
+// sendDM is sendEmbeds with files, at most 10, uploaded with the last message.
+// Hard errors, like a user with DMs closed, fail immediately.
+func sendDM(s *discordgo.Session, channelID string, embeds []*discordgo.MessageEmbed, files ...*discordgo.File) ([]*discordgo.Message, error) {
+	var sent []*discordgo.Message
+	groups := groupEmbeds(embeds)
+	for i, group := range groups {
+		send := &discordgo.MessageSend{Embeds: group, AllowedMentions: noMentions}
+		if i == len(groups)-1 { send.Files = files }
+		var msg *discordgo.Message
+		err := withRetry(func() (err error) {
+			// A failed attempt may have read part of the files
+			for _, f := range send.Files {
+				if r, ok := f.Reader.(io.Seeker); ok { r.Seek(0, io.SeekStart) }
+			}
+			msg, err = s.ChannelMessageSendComplex(channelID, send, manualRateLimit)
+			return err
+		}, dmRetries+1)
+		if err != nil { return sent, err }
+		sent = append(sent, msg)
+	}
+	return sent, nil
+}
+
+// isCannotDM reports whether Discord refused to deliver a DM because the user
+// has DMs disabled or blocked the bot.
+func isCannotDM(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeCannotSendMessagesToThisUser
+}
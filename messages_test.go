@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSplitLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		limit int
+		want  []string
+	}{
+		{"empty", "", 10, []string{""}},
+		{"fits", "hello", 10, []string{"hello"}},
+		{"word boundary", "hello world foo", 10, []string{"hello", "world foo"}},
+		{"newline preferred", "aaa bbb\nccc ddd", 10, []string{"aaa bbb", "ccc ddd"}},
+		{"long word", strings.Repeat("x", 2500), maxMessageLen, []string{strings.Repeat("x", 2000), strings.Repeat("x", 500)}},
+		{"multibyte", strings.Repeat("é", 15), 10, []string{strings.Repeat("é", 10), strings.Repeat("é", 5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitLimit(tt.in, tt.limit)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("splitLimit(%q, %d) = %q, want %q", truncate(tt.in, 40), tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+// A 5000-character message goes out as three messages, in order and with
+// nothing lost but the spaces it was split on.
+func TestSplitLimitLongMessage(t *testing.T) {
+	words := make([]string, 1000)
+	for i := range words {
+		words[i] = "word"
+	}
+	in := strings.Join(words, " ")[:4999] + "!"
+	got := splitLimit(in, maxMessageLen)
+	if len(got) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(got))
+	}
+	for i, c := range got {
+		if n := len([]rune(c)); n > maxMessageLen {
+			t.Errorf("chunk %d is %d characters", i, n)
+		}
+	}
+	if joined := strings.Join(got, " "); joined != in {
+		t.Errorf("chunks don't rejoin to the input in order")
+	}
+}
+
+func TestMassMentionsEscaped(t *testing.T) {
+	got := massMentions.Replace("hey @everyone and @here")
+	if strings.Contains(got, "@everyone") || strings.Contains(got, "@here") {
+		t.Errorf("massMentions left a mass mention in %q", got)
+	}
+}
+
+// Forwarded text and embeds go out with an empty allowed_mentions parse list,
+// so Discord pings nobody whatever the content says.
+func TestForwardsNeverPing(t *testing.T) {
+	s, rec := testSession(t)
+	author := &discordgo.User{ID: "111", Username: "alice"}
+	if _, err := sendText(s, "2", "@everyone look"); err != nil { t.Fatal(err) }
+	if _, err := sendEmbeds(s, "2", userEmbeds(author, "@everyone <@&123>", nil)); err != nil { t.Fatal(err) }
+	if _, err := sendDM(s, "2", staffEmbeds("@here", nil, author)); err != nil { t.Fatal(err) }
+
+	if len(rec.sent) != 3 {
+		t.Fatalf("sent %d messages, want 3", len(rec.sent))
+	}
+	for i, msg := range rec.sent {
+		if msg.AllowedMentions == nil || msg.AllowedMentions.Parse == nil || len(msg.AllowedMentions.Parse) != 0 {
+			t.Errorf("message %d: allowed_mentions = %+v, want an empty parse list", i, msg.AllowedMentions)
+		}
+	}
+	if strings.Contains(rec.sent[0].Content, "@everyone") {
+		t.Errorf("sendText sent %q unescaped", rec.sent[0].Content)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Prometheus metrics, served on /metrics.
+var (
+	messagesForwarded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "modmail_messages_forwarded_total",
+		Help: "Messages relayed between users and staff.",
+	}, []string{"direction"})
+	ticketsOpened = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_tickets_opened_total",
+		Help: "Tickets opened.",
+	})
+	ticketsClosed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_tickets_closed_total",
+		Help: "Tickets closed.",
+	})
+	dmFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_dm_failures_total",
+		Help: "Staff replies that could not be delivered to the user.",
+	})
+	dbErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_db_errors_total",
+		Help: "Failed MongoDB operations.",
+	})
+	apiRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "modmail_discord_retries_total",
+		Help: "Discord API calls retried after a rate limit or server error.",
+	}, []string{"reason"})
+	gatewayReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "modmail_gateway_reconnects_total",
+		Help: "Gateway reconnects and resumes after the first connection.",
+	})
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "modmail_open_tickets",
+		Help: "Tickets currently open.",
+	}, countOpenTickets)
+)
+
+// countOpenTickets is read on every scrape.
+func countOpenTickets() float64 {
+	if TicketCol == nil { return 0 }
+	ctx, cancel := dbCtx()
+	defer cancel()
+	n, err := TicketCol.CountDocuments(ctx, bson.M{"open": true})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Warn("cannot count open tickets", "err", err)
+		return 0
+	}
+	return float64(n)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// modEvent is one administrative action for the mod log.
+type modEvent struct {
+	Action    string          // key into modActions
+	Actor     *discordgo.User // nil when the bot acted on its own
+	UserID    string          // the ticket's user, or the user acted on
+	ChannelID string          // the ticket channel, if there is one
+	Detail    string
+}
+
+var modActions = map[string]struct {
+	title string
+	color int
+}{
+	"open":      {"🎫 Ticket opened", 0x2ecc71},
+	"close":     {"🔒 Ticket closed", 0xe74c3c},
+	"block":     {"⛔ User blocked", 0x992d22},
+	"unblock":   {"✅ User unblocked", 0x3498db},
+	"move":      {"📦 Ticket moved", 0x95a5a6},
+	"claim":     {"🙋 Ticket claimed", 0xf1c40f},
+	"unclaim":   {"👋 Claim released", 0xf1c40f},
+	"transfer":  {"🔁 Ticket transferred", 0xf1c40f},
+	"merge":     {"🔀 Duplicate ticket merged", 0x95a5a6},
+	"broadcast": {"📢 Broadcast sent", 0x3498db},
+	"resolve":   {"✅ Ticket resolved", 0x2ecc71},
+	"reopen":    {"🔓 Ticket reopened", 0x3498db},
+}
+
+// logAction posts ev to MOD_LOG_CHANNEL_ID, so moderators have a trail of who
+// did what apart from the transcripts. Failures are logged and otherwise
+// ignored; the action itself has already happened.
+func logAction(s *discordgo.Session, ev modEvent) {
+	if ModLogChannelID == "" { return }
+	kind, ok := modActions[ev.Action]
+	if !ok { kind.title = ev.Action }
+
+	by := "the system"
+	if ev.Actor != nil { by = ev.Actor.Mention() + " (" + ev.Actor.Username + ")" }
+	embed := &discordgo.MessageEmbed{
+		Title: kind.title,
+		Description: truncate(ev.Detail, maxEmbedDesc),
+		Color: kind.color,
+		Fields: []*discordgo.MessageEmbedField{{Name: "By", Value: by, Inline: true}},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if ev.UserID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "User", Value: "<@" + ev.UserID + "> (" + ev.UserID + ")", Inline: true})
+	}
+	if ev.ChannelID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Ticket", Value: "<#" + ev.ChannelID + ">", Inline: true})
+	}
+	if _, err := sendEmbed(s, ModLogChannelID, embed); err != nil {
+		slog.Warn("cannot post to mod log", "action", ev.Action, "channel_id", ModLogChannelID, "err", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// cmdMove reroutes the ticket to another target's category, named either by
+// target name or category ID. Only categories in the ticket's own guild work,
+// since Discord can't move channels between guilds.
+func cmdMove(c *commandContext) {
+	if features.Threads {
+		sendText(c.s, c.m.ChannelID, "❌ Thread tickets can't be moved.")
+		return
+	}
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"move <category name or ID>`")
+		return
+	}
+
+	target, ok := moveTarget(c.args[0], c.m.GuildID)
+	if !ok {
+		sendText(c.s, c.m.ChannelID, "❌ No ticket category `"+c.args[0]+"` in this server. Available: "+strings.Join(moveTargetNames(c.m.GuildID), ", "))
+		return
+	}
+	if cat := fetchChannel(c.s, target.CategoryID); cat == nil || cat.Type != discordgo.ChannelTypeGuildCategory || cat.GuildID != c.m.GuildID {
+		sendText(c.s, c.m.ChannelID, "❌ `"+target.Name+"` doesn't point at a category in this server.")
+		return
+	}
+
+	if _, err := c.s.ChannelEditComplex(c.m.ChannelID, &discordgo.ChannelEdit{ParentID: target.CategoryID}); err != nil {
+		slog.Error("cannot move ticket", "channel_id", c.m.ChannelID, "category_id", target.CategoryID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not move the ticket.")
+		return
+	}
+	if err := setTicketFields(c.m.ChannelID, bson.M{"category": target.Name}); err != nil {
+		slog.Error("cannot record ticket move", "channel_id", c.m.ChannelID, "err", err)
+	}
+	sendText(c.s, c.m.ChannelID, "📦 Ticket moved to **"+target.Name+"** by "+c.m.Author.Mention()+".")
+	logAction(c.s, modEvent{Action: "move", Actor: c.m.Author, UserID: c.userID, ChannelID: c.m.ChannelID, Detail: "Moved to **" + target.Name + "**"})
+	refreshUserStatus(c.s, c.m.ChannelID)
+}
+
+// moveTarget finds the target in guildID matching a name or category ID.
+func moveTarget(arg, guildID string) (Target, bool) {
+	for _, t := range config.Targets {
+		if t.GuildID == guildID && (strings.EqualFold(t.Name, arg) || t.CategoryID == arg) { return t, true }
+	}
+	return Target{}, false
+}
+
+func moveTargetNames(guildID string) []string {
+	var names []string
+	for _, t := range config.Targets {
+		if t.GuildID == guildID { names = append(names, "`"+t.Name+"`") }
+	}
+	return names
+}
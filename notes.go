@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// cmdNote records an internal note that stays in the ticket channel.
+func cmdNote(c *commandContext) {
+	if c.rest == "" && len(c.m.Attachments) == 0 {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"note <text>`")
+		return
+	}
+	saveNote(c.s, c.m, c.userID, c.rest)
+}
+
+// saveNote logs a staff message as an internal note. Notes are left out of the
+// transcript sent to the user.
+func saveNote(s *discordgo.Session, m *discordgo.MessageCreate, userID, text string) {
+	logToDB(userID, text, "note", len(m.Attachments) > 0)
+	react(s, m.ChannelID, m.ID, "📝")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// notifyNewTicket posts the new-ticket embeds, pinging the staff role during
+// office hours and posting them quietly otherwise. It returns the posted
+// message, or nil if it couldn't be sent.
+func notifyNewTicket(s *discordgo.Session, channelID string, embeds ...*discordgo.MessageEmbed) *discordgo.Message {
+	msg := &discordgo.MessageSend{
+		Embeds:          embeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if role := settings().StaffRoleID; role != "" && PingStaff {
+		if inOfficeHours(time.Now()) {
+			msg.Content = "<@&" + role + ">"
+			msg.AllowedMentions.Roles = []string{role}
+		} else {
+			msg.Content = "🌙 New ticket outside office hours."
+		}
+	}
+	sent, err := sendMessage(s, channelID, msg)
+	if err != nil {
+		slog.Error("cannot post new ticket notice", "channel_id", channelID, "err", err)
+	}
+	return sent
+}
+
+// inOfficeHours reports whether t falls inside OFFICE_HOURS, given as
+// "<start>-<end>" in 24h server-local hours (e.g. "9-17", or "22-6" across
+// midnight). An unset or malformed value means always in hours.
+func inOfficeHours(t time.Time) bool {
+	if OfficeHours == "" { return true }
+	from, to, ok := strings.Cut(OfficeHours, "-")
+	start, err1 := strconv.Atoi(strings.TrimSpace(from))
+	end, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil {
+		slog.Warn("ignoring malformed OFFICE_HOURS", "value", OfficeHours)
+		return true
+	}
+
+	h := t.Hour()
+	if start <= end { return h >= start && h < end }
+	return h >= start || h < end
+}
+
+func newTicketEmbed(number int64, user *discordgo.User) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s #%d", settings().NewTicketTitle, number), Description: "User: " + user.Mention(), Color: settings().StaffColor,
+	}
+}
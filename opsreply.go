@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dispatchOpsCommand handles "reply <userID> <message>", "areply ...",
+// "contact ...", "tickets", "broadcast ..." and "usernote ..." sent in
+// STAFF_OPS_CHANNEL_ID, letting staff reach users without being in their
+// ticket. "tickets" and "list" also work in any other staff guild channel,
+// where they are ignored rather than refused for users without permission.
+func dispatchOpsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !strings.HasPrefix(m.Content, Prefix) { return }
+	body := strings.TrimLeft(strings.TrimPrefix(m.Content, Prefix), " \t")
+	name, rest, _ := strings.Cut(body, " ")
+	name = strings.ToLower(name)
+	inOps := StaffOpsChannelID != "" && m.ChannelID == StaffOpsChannelID
+	var cmd textCommand
+	switch {
+	case !inOps && name != "tickets" && name != "list":
+		return
+	case name == "reply", name == "areply", name == "contact":
+		cmd = contactUser
+	case name == "tickets", name == "list", name == "broadcast", name == "usernote":
+		cmd = textCommands[name]
+	default:
+		return
+	}
+	level := commandLevels[name]
+	// Without staff roles anyone counts as staff, which is only safe in
+	// channels staff already control
+	if !inOps && len(staffRoles) == 0 { level = max(level, permModerator) }
+	if !hasPermission(s, m.GuildID, m.Author.ID, level) {
+		if inOps { denyCommand(s, m) }
+		return
+	}
+	rest = strings.TrimSpace(rest)
+	cmd(&commandContext{s: s, m: m, name: name, args: strings.Fields(rest), rest: rest})
+}
+
+// cmdContact is "contact <user ID> <message>" from a ticket channel.
+func cmdContact(c *commandContext) {
+	contactUser(c)
+}
+
+// contactUser DMs "<user ID> <message>" to a user and files it in their
+// ticket, opening one marked as staff-initiated if they have none. The DM goes
+// first so users who can't receive it don't leave a dead channel behind.
+func contactUser(c *commandContext) {
+	content := skipFields(c.rest, 1)
+	if len(c.args) == 0 || (content == "" && len(c.m.Attachments) == 0) {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+c.name+" <user ID> <message>`")
+		return
+	}
+	user, err := c.s.User(strings.Trim(c.args[0], "<@!>"))
+	if err != nil || user.Bot {
+		sendText(c.s, c.m.ChannelID, "❌ `"+c.args[0]+"` isn't a user I can message.")
+		return
+	}
+	if isBlocked(user.ID) {
+		sendText(c.s, c.m.ChannelID, "❌ "+user.Mention()+" is blocked.")
+		return
+	}
+
+	// Behind the user's earlier forwards, like replies sent in the ticket
+	enqueueForward(user.ID, func() { contactUserNow(c, user) })
+}
+
+// contactUserNow sends a contactUser message, honouring the ticket's relay
+// pause and claim like replies sent in the ticket channel. It runs on a
+// forwarding worker.
+func contactUserNow(c *commandContext, user *discordgo.User) {
+	content := skipFields(c.rest, 1)
+	unlock := lockUser(user.ID)
+	defer unlock()
+	t, err := findOpenTicket(user.ID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", user.ID, "err", err)
+	}
+	if t != nil && t.NoRelay {
+		react(c.s, c.m.ChannelID, c.m.ID, "🔇")
+		sendText(c.s, c.m.ChannelID, "Relaying is paused in <#"+t.ChannelID+">, your message was not sent. Use `"+Prefix+"relay on` there first.")
+		return
+	}
+	if t != nil && t.ClaimedBy != "" && t.ClaimedBy != c.m.Author.ID {
+		react(c.s, c.m.ChannelID, c.m.ID, "⚠️")
+		sendText(c.s, c.m.ChannelID, "<#"+t.ChannelID+"> is claimed by <@"+t.ClaimedBy+">, your message was not sent.")
+		return
+	}
+
+	ch := ticketChannel(c.s, user.ID)
+	ticketID := ""
+	if ch != nil { ticketID = ch.ID }
+
+	var author *discordgo.User
+	if c.name != "areply" { author = c.m.Author }
+	if _, err := relayToUser(c.s, user.ID, c.m.ID, content, c.m.Attachments, author); err != nil {
+		react(c.s, c.m.ChannelID, c.m.ID, "❌")
+		sendText(c.s, c.m.ChannelID, deliveryFailure(ticketID, user.ID, err))
+		return
+	}
+
+	if ch == nil {
+		ch = openTicket(c.s, user, "", resolveTarget(user.ID), c.m.Author)
+		if ch == nil {
+			sendText(c.s, c.m.ChannelID, "⚠️ Message sent, but the ticket channel for "+user.Mention()+" could not be created.")
+			return
+		}
+	}
+	react(c.s, c.m.ChannelID, c.m.ID, settings().SentEmoji)
+	recordStaffReply(c.s, ch.ID, content, c.m.Attachments, author)
+}
+
+// recordStaffReply copies a reply sent from outside the ticket channel into it,
+// so the channel keeps the full conversation.
+func recordStaffReply(s *discordgo.Session, channelID, content string, files []*discordgo.MessageAttachment, author *discordgo.User) {
+	recordFirstResponse(channelID)
+	name, avatar := "Staff", s.State.User.AvatarURL("")
+	if author != nil { name, avatar = author.Username, author.AvatarURL("") }
+	postAs(s, channelID, name, avatar, content, files, staffEmbeds(content, files, author))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// categoryChain is a target's overflow categories, created in order once its
+// own category fills up, and the one new tickets currently go in.
+type categoryChain struct {
+	Target   string   `bson:"_id"`
+	Overflow []string `bson:"overflow"`
+	Active   string   `bson:"active"`
+}
+
+var overflow = struct {
+	sync.Mutex
+	byTarget map[string]*categoryChain
+}{byTarget: map[string]*categoryChain{}}
+
+// loadCategoryChains restores the overflow categories created by earlier runs.
+func loadCategoryChains() {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := CategoryCol.Find(ctx, bson.M{})
+	if err != nil {
+		slog.Error("cannot load overflow categories", "err", err)
+		return
+	}
+	var chains []categoryChain
+	if err = cur.All(ctx, &chains); err != nil {
+		slog.Error("cannot load overflow categories", "err", err)
+		return
+	}
+	overflow.Lock()
+	defer overflow.Unlock()
+	for i := range chains {
+		overflow.byTarget[chains[i].Target] = &chains[i]
+	}
+}
+
+func saveCategoryChain(c *categoryChain) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := CategoryCol.UpdateOne(ctx, bson.M{"_id": c.Target},
+		bson.M{"$set": bson.M{"overflow": c.Overflow, "active": c.Active}}, options.Update().SetUpsert(true))
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot save overflow categories", "target", c.Target, "err", err)
+	}
+}
+
+// isOverflowCategory reports whether categoryID is one of the overflow categories.
+func isOverflowCategory(categoryID string) bool {
+	overflow.Lock()
+	defer overflow.Unlock()
+	for _, c := range overflow.byTarget {
+		for _, id := range c.Overflow {
+			if id == categoryID { return true }
+		}
+	}
+	return false
+}
+
+// ticketCategory returns the category target's next ticket goes in: the first
+// of its categories with fewer than CATEGORY_LIMIT channels, or a new overflow
+// category when they're all full. full is a category Discord has just refused
+// a channel in, which the cached channel counts may not reflect yet.
+func ticketCategory(s *discordgo.Session, target Target, full string) (string, error) {
+	overflow.Lock()
+	defer overflow.Unlock()
+	c := overflow.byTarget[target.Name]
+	if c == nil {
+		c = &categoryChain{Target: target.Name, Active: target.CategoryID}
+		overflow.byTarget[target.Name] = c
+	}
+
+	count := map[string]int{}
+	exists := map[string]bool{}
+	for _, ch := range guildChannels(s, target.GuildID) {
+		if ch.Type == discordgo.ChannelTypeGuildCategory { exists[ch.ID] = true }
+		count[ch.ParentID]++
+	}
+	// Overflow categories deleted by hand are forgotten
+	kept := c.Overflow[:0]
+	for _, id := range c.Overflow {
+		if exists[id] { kept = append(kept, id) }
+	}
+	c.Overflow = kept
+
+	for _, id := range append([]string{target.CategoryID}, c.Overflow...) {
+		if id != full && count[id] < CategoryLimit {
+			switchCategory(s, c, id, chainIndex(c, id) > chainIndex(c, c.Active))
+			return id, nil
+		}
+	}
+
+	base := fetchChannel(s, target.CategoryID)
+	if base == nil { return "", errors.New("ticket category " + target.CategoryID + " not found") }
+	cat, err := s.GuildChannelCreateComplex(target.GuildID, discordgo.GuildChannelCreateData{
+		Name: fmt.Sprintf("%s-%d", base.Name, len(c.Overflow)+2), Type: discordgo.ChannelTypeGuildCategory,
+		Position: base.Position + len(c.Overflow) + 1, PermissionOverwrites: base.PermissionOverwrites,
+	})
+	if err != nil { return "", err }
+	slog.Info("created overflow category", "target", target.Name, "category_id", cat.ID, "name", cat.Name)
+	invalidateChannels(target.GuildID)
+	c.Overflow = append(c.Overflow, cat.ID)
+	switchCategory(s, c, cat.ID, true)
+	return cat.ID, nil
+}
+
+// switchCategory makes categoryID the chain's active category, telling staff
+// in LOG_CHANNEL_ID when that changes. Callers hold the overflow lock. Moving
+// back to an earlier category after it has emptied out is logged quietly.
+func switchCategory(s *discordgo.Session, c *categoryChain, categoryID string, filledUp bool) {
+	if c.Active == categoryID { return }
+	previous := c.Active
+	c.Active = categoryID
+	saveCategoryChain(c)
+	slog.Warn("ticket category changed", "target", c.Target, "from", previous, "to", categoryID)
+	if !filledUp { return }
+	alertAdmins(s, fmt.Sprintf("📁 New **%s** tickets now open in <#%s> instead of <#%s>, which is full.", c.Target, categoryID, previous))
+}
+
+// chainIndex is categoryID's position in the chain, the target's own
+// category being 0.
+func chainIndex(c *categoryChain, categoryID string) int {
+	for i, id := range c.Overflow {
+		if id == categoryID { return i + 1 }
+	}
+	return 0
+}
+
+// isCategoryFull reports whether err is Discord refusing a channel because its
+// category already holds the maximum number of channels.
+func isCategoryFull(err error) bool {
+	var rerr *discordgo.RESTError
+	if !errors.As(err, &rerr) || rerr.Message == nil { return false }
+	return rerr.Message.Code == discordgo.ErrCodeInvalidFormBody && bytes.Contains(rerr.ResponseBody, []byte("CHANNEL_PARENT_MAX_CHANNELS"))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type permLevel int
+
+const (
+	permStaff permLevel = iota
+	permModerator
+	permAdmin
+)
+
+var (
+	// Roles allowed to run staff commands. Empty means anyone in a ticket channel.
+	staffRoles = roleSet(os.Getenv("STAFF_ROLE_IDS"))
+	// Roles allowed to run moderator commands, on top of Manage Channels.
+	modRoles = roleSet(os.Getenv("MOD_ROLE_IDS"))
+	// Roles allowed to run admin commands, on top of Administrator.
+	adminRoles = roleSet(os.Getenv("ADMIN_ROLE_IDS"))
+)
+
+// commandLevels lists commands that need more than permStaff.
+var commandLevels = map[string]permLevel{
+	"block":     permModerator,
+	"unblock":   permModerator,
+	"config":    permModerator,
+	"export":    permModerator,
+	"reload":    permModerator,
+	"backfill":  permModerator,
+	"filter":    permModerator,
+	"autoreply": permModerator,
+	"broadcast": permAdmin,
+}
+
+func roleSet(list string) map[string]bool {
+	set := map[string]bool{}
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" { set[id] = true }
+	}
+	return set
+}
+
+// hasPermission reports whether userID may run commands at level in guildID.
+// Admins are the guild owner and members with an ADMIN_ROLE_IDS role or
+// Administrator; moderators are admins plus MOD_ROLE_IDS and Manage Channels;
+// staff are moderators plus anyone with a STAFF_ROLE_IDS role.
+func hasPermission(s *discordgo.Session, guildID, userID string, level permLevel) bool {
+	if level == permStaff && len(staffRoles) == 0 { return true }
+
+	member, err := s.State.Member(guildID, userID)
+	if err != nil {
+		if member, err = s.GuildMember(guildID, userID); err != nil {
+			slog.Warn("cannot look up member for permission check", "guild_id", guildID, "user_id", userID, "err", err)
+			return false
+		}
+	}
+
+	var perms int64
+	for _, id := range member.Roles {
+		if adminRoles[id] { return true }
+		if level <= permModerator && modRoles[id] { return true }
+		if level == permStaff && staffRoles[id] { return true }
+		if r, err := s.State.Role(guildID, id); err == nil { perms |= r.Permissions }
+	}
+	if g, err := s.State.Guild(guildID); err == nil {
+		if g.OwnerID == userID { return true }
+		// @everyone shares the guild's ID
+		if r, err := s.State.Role(guildID, guildID); err == nil { perms |= r.Permissions }
+	}
+	if level == permAdmin { return perms&discordgo.PermissionAdministrator != 0 }
+	return perms&(discordgo.PermissionAdministrator|discordgo.PermissionManageChannels) != 0
+}
+
+// denyCommand tells the caller off and cleans up after a few seconds.
+func denyCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msgs, _ := sendText(s, m.ChannelID, "⛔ "+m.Author.Mention()+", you don't have permission to use that command.")
+	time.AfterFunc(5*time.Second, func() {
+		for _, id := range append(messageIDs(msgs), m.ID) {
+			s.ChannelMessageDelete(m.ChannelID, id)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cmdPing shows gateway heartbeat latency and a MongoDB round trip.
+func cmdPing(c *commandContext) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	start := time.Now()
+	err := MsgCol.Database().Client().Ping(ctx, nil)
+	mongoRTT := time.Since(start)
+
+	mongo := fmt.Sprintf("%d ms", mongoRTT.Milliseconds())
+	if err != nil {
+		slog.Warn("mongo ping failed", "err", err)
+		mongo = "❌ " + err.Error()
+	}
+	sendEmbed(c.s, c.m.ChannelID, &discordgo.MessageEmbed{
+		Title: "🏓 Pong",
+		Color: 0x3498db,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Gateway", Value: fmt.Sprintf("%d ms", c.s.HeartbeatLatency().Milliseconds()), Inline: true},
+			{Name: "MongoDB", Value: mongo, Inline: true},
+		},
+	})
+}
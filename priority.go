@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// priorityLevel is how a ticket priority shows up in the channel.
+type priorityLevel struct {
+	Prefix string // channel name prefix
+	Color  int
+}
+
+var priorities = map[string]priorityLevel{
+	"low":    {"⚪", 0x95a5a6},
+	"normal": {"", 0x3498db},
+	"high":   {"🟡", 0xf1c40f},
+	"urgent": {"🔴", 0xe74c3c},
+}
+
+// stripPriority removes any priority prefix from a channel name.
+func stripPriority(name string) string {
+	for _, p := range priorities {
+		if p.Prefix != "" { name = strings.TrimPrefix(name, p.Prefix) }
+	}
+	return name
+}
+
+// cmdPriority sets the ticket's priority, tags the channel name with it and
+// pings the staff role for urgent tickets.
+func cmdPriority(c *commandContext) {
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"priority <low|normal|high|urgent>`")
+		return
+	}
+	name := strings.ToLower(c.args[0])
+	level, ok := priorities[name]
+	if !ok {
+		sendText(c.s, c.m.ChannelID, "❌ Priority must be one of low, normal, high or urgent.")
+		return
+	}
+	if err := setTicketFields(c.m.ChannelID, bson.M{"priority": name}); err != nil {
+		slog.Error("cannot set priority", "channel_id", c.m.ChannelID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not set the priority.")
+		return
+	}
+
+	note := ""
+	if ch := fetchChannel(c.s, c.m.ChannelID); ch != nil {
+		renamed := level.Prefix + stripPriority(ch.Name)
+		if renamed != ch.Name {
+			if _, err := c.s.ChannelEdit(ch.ID, &discordgo.ChannelEdit{Name: renamed}); err != nil {
+				// Discord only allows two renames per channel every ten minutes
+				slog.Warn("cannot rename channel for priority", "channel_id", ch.ID, "err", err)
+				note = "\n*The channel could not be renamed; Discord only allows two renames every ten minutes, so run the command again later.*"
+			}
+		}
+	}
+
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Description: "Priority set to **" + name + "** by " + c.m.Author.Mention() + "." + note,
+			Color: level.Color,
+		}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if role := settings().StaffRoleID; name == "urgent" && PingStaff && role != "" {
+		msg.Content = "🚨 <@&" + role + "> urgent ticket"
+		msg.AllowedMentions.Roles = []string{role}
+	}
+	sendMessage(c.s, c.m.ChannelID, msg)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a per-key sliding window limiter.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   map[string][]time.Time
+	warned map[string]bool
+}
+
+var (
+	// Messages forwarded per user
+	msgLimiter = newRateLimiter(5, 10*time.Second)
+	// Brand-new tickets per user
+	ticketLimiter = newRateLimiter(2, 10*time.Minute)
+)
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, hits: map[string][]time.Time{}, warned: map[string]bool{}}
+}
+
+// allow records a hit for key if it is within the limit. When it isn't, warn
+// is true only for the first rejection in the window so callers can notify
+// the user once instead of on every dropped message.
+func (r *rateLimiter) allow(key string) (ok, warn bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if len(r.hits) > 1024 { r.sweep(now) }
+
+	hits := r.recent(key, now)
+	if len(hits) >= r.limit {
+		r.hits[key] = hits
+		warn = !r.warned[key]
+		r.warned[key] = true
+		return false, warn
+	}
+	r.hits[key] = append(hits, now)
+	delete(r.warned, key)
+	return true, false
+}
+
+// recent returns the hits for key that are still inside the window.
+func (r *rateLimiter) recent(key string, now time.Time) []time.Time {
+	hits := r.hits[key]
+	i := 0
+	for i < len(hits) && now.Sub(hits[i]) >= r.window {
+		i++
+	}
+	return hits[i:]
+}
+
+// sweep forgets keys with no hits left in the window.
+func (r *rateLimiter) sweep(now time.Time) {
+	for key := range r.hits {
+		if len(r.recent(key, now)) == 0 {
+			delete(r.hits, key)
+			delete(r.warned, key)
+		}
+	}
+}
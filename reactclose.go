@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const closeEmoji = "🔒"
+
+// addCloseReaction seeds 🔒 on a ticket's new-ticket embed and remembers the
+// message, so staff can close with one click.
+func addCloseReaction(s *discordgo.Session, intro *discordgo.Message) {
+	if err := setTicketFields(intro.ChannelID, bson.M{"intro_message_id": intro.ID}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot save intro message", "channel_id", intro.ChannelID, "err", err)
+		return
+	}
+	if err := react(s, intro.ChannelID, intro.ID, closeEmoji); err != nil {
+		slog.Warn("cannot add close reaction", "channel_id", intro.ChannelID, "err", err)
+	}
+}
+
+// messageReactionAdd starts the close flow when staff react 🔒 on a ticket's
+// new-ticket embed. The reaction is taken off again so it works a second time
+// if the close is cancelled.
+func messageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.GuildID == "" || r.Emoji.Name != closeEmoji || r.UserID == s.State.User.ID { return }
+	t, err := findTicketByChannel(r.ChannelID)
+	if err != nil || t == nil || t.IntroMessageID != r.MessageID { return }
+
+	s.MessageReactionRemove(r.ChannelID, r.MessageID, closeEmoji, r.UserID)
+	if !hasPermission(s, r.GuildID, r.UserID, permStaff) {
+		slog.Debug("ignoring close reaction without permission", "user_id", r.UserID, "channel_id", r.ChannelID)
+		return
+	}
+	askCloseConfirm(s, r.ChannelID, t.UserID, "", false)
+}
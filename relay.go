@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// isSideChatter reports whether a staff message starts with the ignore_prefix
+// setting, marking it as talk between staff that is neither forwarded nor
+// logged. The setting is off when empty or "off".
+func isSideChatter(content string) bool {
+	prefix := settings().IgnorePrefix
+	if prefix == "" || strings.EqualFold(prefix, "off") { return false }
+	return strings.HasPrefix(content, prefix)
+}
+
+// cmdRelay pauses or resumes forwarding for this ticket with "relay off" and
+// "relay on". While paused nothing reaches the user: plain messages stay with
+// staff, and replies and snippets are refused.
+func cmdRelay(c *commandContext) {
+	var arg string
+	if len(c.args) > 0 { arg = strings.ToLower(c.args[0]) }
+	var paused bool
+	switch arg {
+	case "off":
+		paused = true
+	case "on":
+	default:
+		t, err := findTicketByChannel(c.m.ChannelID)
+		if err != nil || t == nil {
+			sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"relay on|off`")
+			return
+		}
+		state := "on, messages here are sent to the user"
+		if t.NoRelay { state = "off, messages here stay with staff" }
+		sendText(c.s, c.m.ChannelID, "🔁 Relaying is "+state+". Usage: `"+Prefix+"relay on|off`")
+		return
+	}
+
+	if err := setTicketFields(c.m.ChannelID, bson.M{"no_relay": paused}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot change relaying", "channel_id", c.m.ChannelID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not change relaying.")
+		return
+	}
+	if paused {
+		logToDB(c.userID, "Relaying paused by "+c.m.Author.Username, "note", false)
+		sendText(c.s, c.m.ChannelID, "🔇 Relaying paused. Messages here won't reach the user until `"+Prefix+"relay on`.")
+		return
+	}
+	logToDB(c.userID, "Relaying resumed by "+c.m.Author.Username, "note", false)
+	sendText(c.s, c.m.ChannelID, "🔁 Relaying resumed.")
+}
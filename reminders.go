@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Reminder is a staff follow-up set with !remind. It lives in the reminders
+// collection until it fires or its ticket closes, so restarts don't drop it.
+type Reminder struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	ChannelID string        `bson:"channel_id"`
+	UserID    string        `bson:"user_id"`
+	StaffID   string        `bson:"staff_id"`
+	Note      string        `bson:"note,omitempty"`
+	DueAt     time.Time     `bson:"due_at"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// reminderTimers maps reminder ID -> its pending timer, so closing a ticket
+// can stop them.
+var reminderTimers = struct {
+	sync.Mutex
+	byID map[bson.ObjectID]*time.Timer
+}{byID: map[bson.ObjectID]*time.Timer{}}
+
+// cmdRemind is "remind <duration> [note]": the caller is pinged in this
+// ticket once the duration is up, unless the ticket closes first.
+func cmdRemind(c *commandContext) {
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"remind <duration> [note]`")
+		return
+	}
+	after, err := parseDuration(c.args[0])
+	if err != nil || after <= 0 {
+		sendText(c.s, c.m.ChannelID, "❌ Invalid duration, try something like `2h`, `30m` or `1d`.")
+		return
+	}
+
+	now := time.Now()
+	r := Reminder{ID: bson.NewObjectID(), ChannelID: c.m.ChannelID, UserID: c.userID, StaffID: c.m.Author.ID,
+		Note: skipFields(c.rest, 1), DueAt: now.Add(after), CreatedAt: now}
+	ctx, cancel := dbCtx()
+	defer cancel()
+	if _, err := ReminderCol.InsertOne(ctx, r); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot save reminder", "channel_id", r.ChannelID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not set the reminder.")
+		return
+	}
+	scheduleReminder(c.s, r)
+	sendText(c.s, c.m.ChannelID, "⏰ I'll remind you "+fmtTime(r.DueAt)+".")
+}
+
+func scheduleReminder(s *discordgo.Session, r Reminder) {
+	reminderTimers.Lock()
+	defer reminderTimers.Unlock()
+	reminderTimers.byID[r.ID] = time.AfterFunc(time.Until(r.DueAt), func() { fireReminder(s, r) })
+}
+
+// fireReminder pings the staff member who set r, if its ticket is still open.
+func fireReminder(s *discordgo.Session, r Reminder) {
+	reminderTimers.Lock()
+	delete(reminderTimers.byID, r.ID)
+	reminderTimers.Unlock()
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	res, err := ReminderCol.DeleteOne(ctx, bson.M{"_id": r.ID})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot delete reminder", "channel_id", r.ChannelID, "err", err)
+		return
+	}
+	// Cancelled by a close that raced the timer
+	if res.DeletedCount == 0 { return }
+	if t, _ := findTicketByChannel(r.ChannelID); t == nil { return }
+
+	msg := fmt.Sprintf("⏰ <@%s>, reminder to follow up on this ticket (set %s).", r.StaffID, fmtTime(r.CreatedAt))
+	if r.Note != "" { msg += "\n> " + r.Note }
+	sendMessage(s, r.ChannelID, &discordgo.MessageSend{
+		Content:         massMentions.Replace(msg),
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{r.StaffID}},
+	})
+}
+
+// cancelReminders drops every reminder for a closing ticket.
+func cancelReminders(channelID string) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := ReminderCol.Find(ctx, bson.M{"channel_id": channelID})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot list reminders", "channel_id", channelID, "err", err)
+		return
+	}
+	var reminders []Reminder
+	if err = cur.All(ctx, &reminders); err != nil { return }
+	if len(reminders) == 0 { return }
+
+	reminderTimers.Lock()
+	for _, r := range reminders {
+		if t := reminderTimers.byID[r.ID]; t != nil { t.Stop() }
+		delete(reminderTimers.byID, r.ID)
+	}
+	reminderTimers.Unlock()
+	if _, err := ReminderCol.DeleteMany(ctx, bson.M{"channel_id": channelID}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot delete reminders", "channel_id", channelID, "err", err)
+	}
+}
+
+// loadReminders reschedules reminders saved before a restart. Ones that came
+// due while the bot was down fire straight away.
+func loadReminders(s *discordgo.Session) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := ReminderCol.Find(ctx, bson.M{})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load reminders", "err", err)
+		return
+	}
+	var reminders []Reminder
+	if err = cur.All(ctx, &reminders); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load reminders", "err", err)
+		return
+	}
+	for _, r := range reminders {
+		scheduleReminder(s, r)
+	}
+	if len(reminders) > 0 { slog.Info("rescheduled reminders", "count", len(reminders)) }
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// labelCleaner matches runs of characters Discord won't keep in a channel name.
+var labelCleaner = regexp.MustCompile("[^a-z0-9_-]+")
+
+// cleanLabel turns free text into a channel-name suffix: lowercase, with
+// anything else collapsed into single dashes.
+func cleanLabel(s string) string {
+	return strings.Trim(labelCleaner.ReplaceAllString(strings.ToLower(s), "-"), "-_")
+}
+
+// cmdRename replaces the part of the channel name after "ticket-<number>-"
+// with a subject, keeping any priority prefix. Only the name is edited, so the
+// topic carrying the user ID is untouched.
+func cmdRename(c *commandContext) {
+	label := cleanLabel(c.rest)
+	if label == "" {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"rename <subject>`")
+		return
+	}
+	t, err := findTicketByChannel(c.m.ChannelID)
+	if err != nil || t == nil {
+		sendText(c.s, c.m.ChannelID, "❌ There's no open ticket in this channel.")
+		return
+	}
+	ch := fetchChannel(c.s, c.m.ChannelID)
+	if ch == nil { return }
+
+	plain := stripPriority(ch.Name)
+	prefix := ticketNamePrefix(t.Number, t.UserID)
+	label = truncateBytes(label, maxChannelName-len(prefix))
+	name := strings.TrimSuffix(ch.Name, plain) + prefix + label
+
+	if _, err := c.s.ChannelEdit(ch.ID, &discordgo.ChannelEdit{Name: name}); err != nil {
+		slog.Warn("cannot rename ticket channel", "channel_id", ch.ID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not rename the channel; Discord only allows two renames every ten minutes.")
+		return
+	}
+	if err := setTicketFields(ch.ID, bson.M{"label": label}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot save ticket label", "channel_id", ch.ID, "err", err)
+	}
+	sendText(c.s, c.m.ChannelID, "🏷️ Renamed to `"+name+"`.")
+}
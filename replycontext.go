@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// replyQuote renders the message m replies to as a one-line quote, with a jump
+// link to its counterpart on the other side when we forwarded it. It returns
+// "" when m isn't a reply.
+func replyQuote(s *discordgo.Session, m *discordgo.Message) string {
+	ref := m.ReferencedMessage
+	if ref == nil { return "" }
+
+	quote := "> ↪ " + quotedText(ref)
+	if channelID, messageID, ok := counterpart(ref.ID); ok {
+		guild := "@me"
+		if ch := fetchChannel(s, channelID); ch != nil && ch.GuildID != "" { guild = ch.GuildID }
+		quote += " ([jump](https://discord.com/channels/" + guild + "/" + channelID + "/" + messageID + "))"
+	}
+	return quote + "\n"
+}
+
+// quotedText is the first line of what ref says, skipping any reply quote of
+// its own. Forwarded copies keep their text in the embed.
+func quotedText(ref *discordgo.Message) string {
+	text := ref.Content
+	if text == "" && len(ref.Embeds) > 0 { text = ref.Embeds[0].Description }
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, ">") {
+			return truncate(line, 100)
+		}
+	}
+	if len(ref.Attachments) > 0 { return "*(attachment)*" }
+	return "*(message)*"
+}
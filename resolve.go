@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Ticket statuses. Tickets saved before statuses were tracked have none and
+// count as open.
+const (
+	statusOpen     = "open"
+	statusResolved = "resolved"
+	statusClosed   = "closed"
+)
+
+// cmdResolve marks the ticket resolved and asks the user to confirm. It
+// closes on its own after RESOLVE_TIMEOUT_HOURS through the scheduled close,
+// unless the user reopens it or anyone writes in the meantime.
+func cmdResolve(c *commandContext) {
+	t, err := findTicketByChannel(c.m.ChannelID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "channel_id", c.m.ChannelID, "err", err)
+	}
+	if t == nil {
+		sendText(c.s, c.m.ChannelID, "❌ There's no open ticket in this channel.")
+		return
+	}
+	if t.Status == statusResolved {
+		sendText(c.s, c.m.ChannelID, "This ticket is already waiting for the user to confirm.")
+		return
+	}
+
+	now := time.Now()
+	err = setTicketFields(c.m.ChannelID, bson.M{
+		"status": statusResolved, "resolved_at": now, "resolved_by": c.m.Author.ID,
+		"close_at": now.Add(ResolveTimeout), "close_scheduled_at": now, "close_scheduled_by": c.m.Author.ID,
+		"close_reason": tr("resolved_reason"),
+	})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot resolve ticket", "channel_id", c.m.ChannelID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not mark the ticket resolved.")
+		return
+	}
+	logToDB(c.userID, "Ticket marked resolved by "+c.m.Author.Username, "system", false)
+	logAction(c.s, modEvent{Action: "resolve", Actor: c.m.Author, UserID: c.userID, ChannelID: c.m.ChannelID})
+
+	msg := fmt.Sprintf("✅ Marked resolved. The ticket closes in %s unless the user reopens it.", ResolveTimeout)
+	if err := askToConfirm(c.s, c.userID); err != nil {
+		slog.Warn("cannot ask user to confirm resolution", "user_id", c.userID, "err", err)
+		msg += " The user couldn't be asked to confirm: " + err.Error()
+	}
+	sendText(c.s, c.m.ChannelID, msg)
+}
+
+// askToConfirm DMs the user the resolved prompt with its two buttons.
+func askToConfirm(s *discordgo.Session, userID string) error {
+	dm, err := openDM(s, userID)
+	if err != nil { return err }
+	_, err = sendMessage(s, dm.ID, &discordgo.MessageSend{
+		Content: tr("resolve_prompt", ResolveTimeout),
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: tr("resolve_yes"), Style: discordgo.SuccessButton, CustomID: "resolve:yes"},
+			discordgo.Button{Label: tr("resolve_reopen"), Style: discordgo.SecondaryButton, CustomID: "resolve:reopen"},
+		}}},
+		AllowedMentions: noMentions,
+	})
+	return err
+}
+
+// handleResolve is the user's answer to the resolved prompt; arg is "yes" or
+// "reopen". The prompt's buttons are removed either way.
+func handleResolve(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.User == nil { return }
+	userID := i.User.ID
+	unlock := lockUser(userID)
+	defer unlock()
+	t, err := findOpenTicket(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", userID, "err", err)
+	}
+
+	status := tr("resolve_expired")
+	if t != nil && t.Status == statusResolved {
+		status = tr("resolve_reopened")
+		if arg == "yes" { status = tr("resolve_confirmed") }
+	}
+	empty := []discordgo.MessageComponent{}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: status, Components: empty},
+	})
+	if t == nil || t.Status != statusResolved { return }
+
+	if arg == "yes" {
+		slog.Info("resolution confirmed by user", "user_id", userID, "channel_id", t.ChannelID)
+		sendText(s, t.ChannelID, "✅ The user confirmed this ticket is resolved.")
+		closeTicket(s, t.ChannelID, userID, i.User, tr("resolved_reason"), false)
+		return
+	}
+	reopenTicket(s, *t, i.User)
+}
+
+// reopenTicket takes a resolved ticket back to open and drops its pending
+// close. A nil by means a new message arrived.
+func reopenTicket(s *discordgo.Session, t Ticket, by *discordgo.User) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketCol.UpdateOne(ctx,
+		bson.M{"channel_id": t.ChannelID, "open": true},
+		bson.M{
+			"$set":   bson.M{"status": statusOpen},
+			"$unset": bson.M{"resolved_at": "", "resolved_by": "", "close_at": "", "close_scheduled_at": "", "close_scheduled_by": "", "close_reason": ""},
+		})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot reopen ticket", "channel_id", t.ChannelID, "err", err)
+		return
+	}
+
+	note := "🔓 Ticket reopened because a new message arrived."
+	if by != nil { note = "🔓 Ticket reopened by " + by.Mention() + "." }
+	sendText(s, t.ChannelID, note)
+	logAction(s, modEvent{Action: "reopen", Actor: by, UserID: t.UserID, ChannelID: t.ChannelID})
+}
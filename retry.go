@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// apiAttempts is how many times withRetry tries a send, reaction or channel
+// creation before giving up.
+const apiAttempts = 3
+
+// maxRetryWait caps a single wait, so one long Retry-After can't stall a
+// forwarding worker.
+const maxRetryWait = 30 * time.Second
+
+// manualRateLimit makes discordgo hand a 429 back rather than sleeping on it
+// silently, so withRetry can log it and count it against the attempts. Pass it
+// to calls made inside withRetry.
+var manualRateLimit = discordgo.WithRetryOnRatelimit(false)
+
+// withRetry calls fn up to attempts times. Rate limits are retried after the
+// Retry-After Discord asks for, server errors after an exponential backoff.
+// Anything else, like missing permissions or closed DMs, fails immediately.
+func withRetry(fn func() error, attempts int) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts { return err }
+		reason, wait := retryDelay(err, attempt)
+		if reason == "" { return err }
+		apiRetries.WithLabelValues(reason).Inc()
+		slog.Warn("discord request failed, retrying", "reason", reason, "attempt", attempt, "wait", wait, "err", err)
+		time.Sleep(wait)
+	}
+}
+
+// retryDelay says why err is worth retrying and how long to wait first. The
+// reason is empty for hard errors.
+func retryDelay(err error, attempt int) (reason string, wait time.Duration) {
+	var rl *discordgo.RateLimitError
+	if errors.As(err, &rl) { return "rate_limit", min(rl.RetryAfter, maxRetryWait) }
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil { return "", 0 }
+	switch code := restErr.Response.StatusCode; {
+	case code == http.StatusTooManyRequests:
+		secs, _ := strconv.ParseFloat(restErr.Response.Header.Get("Retry-After"), 64)
+		return "rate_limit", min(time.Duration(secs*float64(time.Second)), maxRetryWait)
+	case code >= 500:
+		return "server", time.Duration(1<<(attempt-1)) * time.Second
+	}
+	return "", 0
+}
+
+// react adds emoji to a message, retrying rate limits.
+func react(s *discordgo.Session, channelID, messageID, emoji string) error {
+	return withRetry(func() error { return s.MessageReactionAdd(channelID, messageID, emoji, manualRateLimit) }, apiAttempts)
+}
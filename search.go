@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const searchPageSize = 8
+
+// searchQuery is what a results message is showing, so its buttons can page.
+type searchQuery struct {
+	text   string
+	userID string
+}
+
+// searches maps results message ID -> query. Buttons on messages from before a
+// restart report the search as expired.
+var searches = struct {
+	sync.Mutex
+	byMessage map[string]searchQuery
+}{byMessage: map[string]searchQuery{}}
+
+// cmdSearch runs "search [--user <id>] <query>" over every logged message.
+func cmdSearch(c *commandContext) {
+	q := searchQuery{text: c.rest}
+	if len(c.args) >= 2 && c.args[0] == "--user" {
+		q.userID = strings.Trim(c.args[1], "<@!>")
+		q.text = skipFields(c.rest, 2)
+	}
+	if q.text == "" {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"search [--user <id>] <query>`")
+		return
+	}
+
+	embed, components, err := searchPage(q, 0)
+	if err != nil {
+		slog.Error("cannot search messages", "query", q.text, "user_id", q.userID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Search failed.")
+		return
+	}
+	msg, err := sendMessage(c.s, c.m.ChannelID, &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components})
+	if err != nil { return }
+	searches.Lock()
+	searches.byMessage[msg.ID] = q
+	searches.Unlock()
+}
+
+// handleSearchPage flips pages; arg is the page number.
+func handleSearchPage(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !hasPermission(s, i.GuildID, i.Member.User.ID, commandLevels["search"]) {
+		respondEphemeral(s, i, "⛔ You don't have permission to use that command.")
+		return
+	}
+	searches.Lock()
+	q, ok := searches.byMessage[i.Message.ID]
+	searches.Unlock()
+	if !ok {
+		respondEphemeral(s, i, "This search has expired, please run it again.")
+		return
+	}
+
+	page, _ := strconv.Atoi(arg)
+	embed, components, err := searchPage(q, page)
+	if err != nil {
+		slog.Error("cannot search messages", "query", q.text, "user_id", q.userID, "err", err)
+		respondEphemeral(s, i, "❌ Search failed.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components},
+	})
+}
+
+// searchPage renders one page of matches, best first, grouped by user.
+func searchPage(q searchQuery, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	filter := bson.M{"$text": bson.M{"$search": q.text}}
+	if q.userID != "" { filter["user_id"] = q.userID }
+	total, err := MsgCol.CountDocuments(ctx, filter)
+	if err != nil { return nil, nil, err }
+	pages := int((total + searchPageSize - 1) / searchPageSize)
+	if pages == 0 { pages = 1 }
+	page = max(0, min(page, pages-1))
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetSkip(int64(page * searchPageSize)).
+		SetLimit(searchPageSize)
+	cur, err := MsgCol.Find(ctx, filter, opts)
+	if err != nil { return nil, nil, err }
+	var logs []ModmailLog
+	if err = cur.All(ctx, &logs); err != nil { return nil, nil, err }
+
+	title := "🔎 " + truncate(q.text, 100)
+	if q.userID != "" { title += " · " + q.userID }
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Color: 0x95a5a6,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d · %d matches", page+1, pages, total)},
+	}
+	if len(logs) == 0 {
+		embed.Description = "No matches."
+	}
+
+	var users []string
+	byUser := map[string][]string{}
+	for _, l := range logs {
+		if _, ok := byUser[l.UserID]; !ok { users = append(users, l.UserID) }
+		byUser[l.UserID] = append(byUser[l.UserID], fmt.Sprintf("%s **%s**: %s", fmtTime(l.Timestamp), l.Sender, truncate(l.Content, 80)))
+	}
+	for _, u := range users {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  u,
+			Value: truncate(strings.Join(byUser[u], "\n"), 1024),
+		})
+	}
+
+	components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "◀ Previous", Style: discordgo.SecondaryButton, Disabled: page == 0,
+			CustomID: fmt.Sprintf("search:%d", page-1)},
+		discordgo.Button{Label: "Next ▶", Style: discordgo.SecondaryButton, Disabled: page >= pages-1,
+			CustomID: fmt.Sprintf("search:%d", page+1)},
+	}}}
+	return embed, components, nil
+}
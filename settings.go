@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Settings is the deployment's branding, stored in the settings collection
+// under the staff guild's ID. Fields missing from the document keep their
+// defaults.
+type Settings struct {
+	UserColor      int    `bson:"user_color"`
+	StaffColor     int    `bson:"staff_color"`
+	ReceivedEmoji  string `bson:"received_emoji"`
+	SentEmoji      string `bson:"sent_emoji"`
+	CreatedTitle   string `bson:"created_title"`
+	StaffTitle     string `bson:"staff_title"`
+	NewTicketTitle string `bson:"new_ticket_title"`
+	// Sent to the user when a ticket opens; {user} and {guild} are filled in
+	// and "off" disables it
+	WelcomeMessage string `bson:"welcome_message"`
+	// Set by !away and cleared by !here; new DMs get it as an auto-reply
+	AwayMessage string `bson:"away_message"`
+	// Pinged for new, urgent and overdue tickets; defaults to STAFF_ROLE_ID
+	StaffRoleID string `bson:"staff_role_id"`
+	// Staff messages starting with this are never forwarded; "off" disables it
+	IgnorePrefix string `bson:"ignore_prefix"`
+}
+
+var defaultSettings = Settings{
+	UserColor:      0x2ecc71,
+	StaffColor:     0x3498db,
+	ReceivedEmoji:  "📩",
+	SentEmoji:      "✅",
+	CreatedTitle:   catalog["created_title"],
+	StaffTitle:     catalog["staff_title"],
+	NewTicketTitle: "🆕 New Ticket",
+	WelcomeMessage: catalog["welcome_message"],
+	StaffRoleID:    StaffRoleID,
+	IgnorePrefix:   "//",
+}
+
+var currentSettings = struct {
+	sync.RWMutex
+	Settings
+}{Settings: defaultSettings}
+
+// settings returns a snapshot of the current settings.
+func settings() Settings {
+	currentSettings.RLock()
+	defer currentSettings.RUnlock()
+	return currentSettings.Settings
+}
+
+func loadSettings() error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	st := defaultSettings
+	err := SettingsCol.FindOne(ctx, bson.M{"_id": GuildID}).Decode(&st)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) { return err }
+
+	currentSettings.Lock()
+	currentSettings.Settings = st
+	currentSettings.Unlock()
+	return nil
+}
+
+// settingKeys maps the bson name of each setting to whether it's a color.
+var settingKeys = map[string]bool{
+	"user_color": true, "staff_color": true,
+	"received_emoji": false, "sent_emoji": false,
+	"created_title": false, "staff_title": false, "new_ticket_title": false,
+	"welcome_message": false, "away_message": false, "staff_role_id": false,
+	"ignore_prefix": false,
+}
+
+func setSetting(key, value string) error {
+	isColor, ok := settingKeys[key]
+	if !ok { return fmt.Errorf("unknown setting %q", key) }
+
+	var v any = value
+	if isColor {
+		n, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimPrefix(value, "#"), "0x"), 16, 32)
+		if err != nil { return fmt.Errorf("%q is not a hex color", value) }
+		v = int(n)
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := SettingsCol.UpdateOne(ctx, bson.M{"_id": GuildID}, bson.M{"$set": bson.M{key: v}}, options.Update().SetUpsert(true))
+	if err != nil { return err }
+	return loadSettings()
+}
+
+// cmdConfig shows the settings, or changes them with "config set <key> <value>"
+// and "config reload".
+func cmdConfig(c *commandContext) {
+	if len(c.args) > 0 {
+		switch strings.ToLower(c.args[0]) {
+		case "set":
+			if len(c.args) < 3 {
+				sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"config set <key> <value>`")
+				return
+			}
+			if err := setSetting(strings.ToLower(c.args[1]), skipFields(c.rest, 2)); err != nil {
+				sendText(c.s, c.m.ChannelID, "❌ "+err.Error())
+				return
+			}
+		case "reload":
+			if err := loadSettings(); err != nil {
+				slog.Error("cannot reload settings", "err", err)
+				sendText(c.s, c.m.ChannelID, "❌ Could not reload settings.")
+				return
+			}
+		}
+	}
+
+	st := settings()
+	role := "none"
+	if st.StaffRoleID != "" { role = "<@&" + st.StaffRoleID + ">" }
+	ignore := "off"
+	if st.IgnorePrefix != "" && !strings.EqualFold(st.IgnorePrefix, "off") { ignore = "`" + st.IgnorePrefix + "`" }
+	sendEmbed(c.s, c.m.ChannelID, &discordgo.MessageEmbed{
+		Title: "⚙️ Settings",
+		Color: st.StaffColor,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "user_color", Value: fmt.Sprintf("#%06x", st.UserColor), Inline: true},
+			{Name: "staff_color", Value: fmt.Sprintf("#%06x", st.StaffColor), Inline: true},
+			{Name: "received_emoji", Value: st.ReceivedEmoji, Inline: true},
+			{Name: "sent_emoji", Value: st.SentEmoji, Inline: true},
+			{Name: "created_title", Value: st.CreatedTitle},
+			{Name: "staff_title", Value: st.StaffTitle},
+			{Name: "new_ticket_title", Value: st.NewTicketTitle},
+			{Name: "welcome_message", Value: truncate(st.WelcomeMessage, 1024)},
+			{Name: "staff_role_id", Value: role, Inline: true},
+			{Name: "ignore_prefix", Value: ignore, Inline: true},
+		},
+	})
+}
+
+// cmdReload re-reads the settings, blocklist and content filters from the
+// database, for changes made outside the bot, and reports what changed.
+func cmdReload(c *commandContext) {
+	before, blockedBefore, filtersBefore := settings(), blocklistSize(), filterCount()
+	if err := loadSettings(); err != nil {
+		slog.Error("cannot reload settings", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not reload settings.")
+		return
+	}
+	if err := loadBlocklist(); err != nil {
+		slog.Error("cannot reload blocklist", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Settings reloaded, but the blocklist could not be.")
+		return
+	}
+	if err := loadFilters(); err != nil {
+		slog.Error("cannot reload content filters", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Settings and blocklist reloaded, but the content filters could not be.")
+		return
+	}
+
+	changes := settingChanges(before, settings())
+	if n := blocklistSize(); n != blockedBefore {
+		changes = append(changes, fmt.Sprintf("blocklist: %d → %d users", blockedBefore, n))
+	}
+	if n := filterCount(); n != filtersBefore {
+		changes = append(changes, fmt.Sprintf("content filters: %d → %d", filtersBefore, n))
+	}
+	slog.Info("configuration reloaded", "by", c.m.Author.ID, "changes", len(changes))
+	if len(changes) == 0 {
+		sendText(c.s, c.m.ChannelID, "🔄 Reloaded, nothing changed.")
+		return
+	}
+	sendText(c.s, c.m.ChannelID, "🔄 Reloaded:\n"+strings.Join(changes, "\n"))
+}
+
+// settingChanges lists the settings that differ between a and b by their
+// database names.
+func settingChanges(a, b Settings) []string {
+	var changes []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		x, y := va.Field(i).Interface(), vb.Field(i).Interface()
+		if x == y { continue }
+		key := va.Type().Field(i).Tag.Get("bson")
+		if settingKeys[key] {
+			changes = append(changes, fmt.Sprintf("`%s`: #%06x → #%06x", key, x, y))
+		} else {
+			changes = append(changes, fmt.Sprintf("`%s`: %q → %q", key, truncate(fmt.Sprint(x), 100), truncate(fmt.Sprint(y), 100)))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// recordFirstResponse stamps the open ticket in channelID with the time staff
+// first answered it. Later replies leave the stamp alone.
+func recordFirstResponse(channelID string) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketCol.UpdateOne(ctx,
+		bson.M{"channel_id": channelID, "open": true, "first_staff_response_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"first_staff_response_at": time.Now()}})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot record first response", "channel_id", channelID, "err", err)
+	}
+}
+
+// firstResponseTime is how long the ticket waited for staff, if they've answered.
+func firstResponseTime(t Ticket) (time.Duration, bool) {
+	if t.FirstStaffResponseAt.IsZero() { return 0, false }
+	return t.FirstStaffResponseAt.Sub(t.CreatedAt), true
+}
+
+// checkSLA warns staff, once, about a ticket that has gone unanswered for
+// longer than SLA_MINUTES. Tickets staff opened themselves can't breach.
+func checkSLA(s *discordgo.Session, t Ticket, now time.Time) {
+	if SLATarget == 0 || t.OpenedBy != "" || !t.SLABreachedAt.IsZero() { return }
+	if _, answered := firstResponseTime(t); answered || now.Sub(t.CreatedAt) < SLATarget { return }
+
+	if err := setTicketFields(t.ChannelID, bson.M{"sla_breached_at": now}); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot record SLA breach", "user_id", t.UserID, "channel_id", t.ChannelID, "err", err)
+		return
+	}
+	slog.Warn("ticket breached SLA", "user_id", t.UserID, "channel_id", t.ChannelID, "waiting", now.Sub(t.CreatedAt))
+
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Description: fmt.Sprintf("⏰ This ticket has waited %s without a staff reply, past the %s response target.",
+				formatResponse(now.Sub(t.CreatedAt)), formatResponse(SLATarget)),
+			Color: priorities["urgent"].Color,
+		}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if role := settings().StaffRoleID; PingStaff && role != "" {
+		msg.Content = "<@&" + role + ">"
+		msg.AllowedMentions.Roles = []string{role}
+	}
+	if _, err := sendMessage(s, t.ChannelID, msg); err != nil {
+		slog.Error("cannot post SLA warning", "channel_id", t.ChannelID, "err", err)
+	}
+}
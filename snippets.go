@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Snippet struct {
+	GuildID string `bson:"guild_id"`
+	Name    string `bson:"name"`
+	Text    string `bson:"text"`
+}
+
+func findSnippet(guildID, name string) (*Snippet, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var sn Snippet
+	err := SnippetCol.FindOne(ctx, bson.M{"guild_id": guildID, "name": name}).Decode(&sn)
+	if errors.Is(err, mongo.ErrNoDocuments) { return nil, nil }
+	if err != nil { return nil, err }
+	return &sn, nil
+}
+
+func saveSnippet(sn Snippet) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := SnippetCol.UpdateOne(ctx,
+		bson.M{"guild_id": sn.GuildID, "name": sn.Name},
+		bson.M{"$set": sn},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func deleteSnippet(guildID, name string) (bool, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	res, err := SnippetCol.DeleteOne(ctx, bson.M{"guild_id": guildID, "name": name})
+	if err != nil { return false, err }
+	return res.DeletedCount > 0, nil
+}
+
+func listSnippets(guildID string) ([]Snippet, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := SnippetCol.Find(ctx, bson.M{"guild_id": guildID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil { return nil, err }
+	var snippets []Snippet
+	err = cur.All(ctx, &snippets)
+	return snippets, err
+}
+
+// expandSnippet fills in the placeholders a snippet may use.
+func expandSnippet(text, userID string) string {
+	return strings.ReplaceAll(text, "{user}", "<@"+userID+">")
+}
+
+// cmdSnippet handles "snippet add <name> <text>", "snippet del <name>" and
+// "snippet list".
+func cmdSnippet(c *commandContext) {
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, fmt.Sprintf("Usage: `%[1]ssnippet add <name> <text>`, `%[1]ssnippet del <name>`, `%[1]ssnippet list`", Prefix))
+		return
+	}
+
+	switch strings.ToLower(c.args[0]) {
+	case "add":
+		if len(c.args) < 3 {
+			sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"snippet add <name> <text>`")
+			return
+		}
+		name := strings.ToLower(c.args[1])
+		if _, builtin := textCommands[name]; builtin {
+			sendText(c.s, c.m.ChannelID, "❌ `"+name+"` is a built-in command.")
+			return
+		}
+		if err := saveSnippet(Snippet{GuildID: c.m.GuildID, Name: name, Text: skipFields(c.rest, 2)}); err != nil {
+			slog.Error("cannot save snippet", "snippet", name, "err", err)
+			sendText(c.s, c.m.ChannelID, "❌ Could not save the snippet.")
+			return
+		}
+		sendText(c.s, c.m.ChannelID, "✅ Saved snippet `"+name+"`.")
+	case "del", "delete", "remove":
+		if len(c.args) < 2 {
+			sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"snippet del <name>`")
+			return
+		}
+		name := strings.ToLower(c.args[1])
+		found, err := deleteSnippet(c.m.GuildID, name)
+		switch {
+		case err != nil:
+			slog.Error("cannot delete snippet", "snippet", name, "err", err)
+			sendText(c.s, c.m.ChannelID, "❌ Could not delete the snippet.")
+		case !found:
+			sendText(c.s, c.m.ChannelID, "No snippet named `"+name+"`.")
+		default:
+			sendText(c.s, c.m.ChannelID, "🗑️ Deleted snippet `"+name+"`.")
+		}
+	case "list":
+		snippets, err := listSnippets(c.m.GuildID)
+		if err != nil {
+			slog.Error("cannot list snippets", "guild_id", c.m.GuildID, "err", err)
+			sendText(c.s, c.m.ChannelID, "❌ Could not load snippets.")
+			return
+		}
+		if len(snippets) == 0 {
+			sendText(c.s, c.m.ChannelID, "No snippets yet.")
+			return
+		}
+		var names []string
+		for _, sn := range snippets {
+			names = append(names, "`"+Prefix+sn.Name+"`")
+		}
+		sendText(c.s, c.m.ChannelID, "📋 Snippets: "+strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// responseWindow bounds the first-response average so it reflects current
+// staffing and doesn't scan the whole history.
+const responseWindow = 30 * 24 * time.Hour
+
+type modmailStats struct {
+	Open, Today, Week int64
+	Urgent            int64            // open urgent tickets
+	Breaches          int64            // SLA breaches within responseWindow
+	Overdue           int64            // open tickets past the SLA and still unanswered
+	Messages          map[string]int64 // by sender
+	AvgResponse       time.Duration
+	Answered          int64
+}
+
+// cmdStats posts ticket and message totals for the whole modmail.
+func cmdStats(c *commandContext) {
+	st, err := gatherStats()
+	if err != nil {
+		slog.Error("cannot gather stats", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not load stats.")
+		return
+	}
+
+	var total int64
+	for _, n := range st.Messages { total += n }
+	response := "No replies yet"
+	if st.Answered > 0 { response = fmt.Sprintf("%s (%d tickets)", formatResponse(st.AvgResponse), st.Answered) }
+	sla := "No target set"
+	if SLATarget > 0 { sla = fmt.Sprintf("%d in 30 days, %d still unanswered (target %s)", st.Breaches, st.Overdue, formatResponse(SLATarget)) }
+
+	sendEmbed(c.s, c.m.ChannelID, &discordgo.MessageEmbed{
+		Title: "📊 Modmail stats",
+		Color: 0x3498db,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Open tickets", Value: fmt.Sprint(st.Open), Inline: true},
+			{Name: "Open urgent", Value: fmt.Sprint(st.Urgent), Inline: true},
+			{Name: "Opened today", Value: fmt.Sprint(st.Today), Inline: true},
+			{Name: "Opened this week", Value: fmt.Sprint(st.Week), Inline: true},
+			{Name: "Total messages", Value: fmt.Sprint(total), Inline: true},
+			{Name: "From users", Value: fmt.Sprint(st.Messages["user"]), Inline: true},
+			{Name: "From staff", Value: fmt.Sprint(st.Messages["staff"]), Inline: true},
+			{Name: "Avg. first response (30 days)", Value: response},
+			{Name: "SLA breaches", Value: sla},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// gatherStats runs the aggregations behind !stats. Everything is counted
+// server-side; only the totals come back.
+func gatherStats() (*modmailStats, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+
+	now := time.Now()
+	y, mo, d := now.Date()
+	today := time.Date(y, mo, d, 0, 0, 0, 0, now.Location())
+	countSince := func(t time.Time) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$created_at", t}}, 1, 0}}}
+	}
+	breached := bson.M{"$ne": bson.A{bson.M{"$type": "$sla_breached_at"}, "missing"}}
+	unanswered := bson.M{"$eq": bson.A{bson.M{"$type": "$first_staff_response_at"}, "missing"}}
+
+	st := &modmailStats{Messages: map[string]int64{}}
+
+	cur, err := TicketCol.Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{
+			"_id":  nil,
+			"open": bson.M{"$sum": bson.M{"$cond": bson.A{"$open", 1, 0}}},
+			"urgent": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{"$open", bson.M{"$eq": bson.A{"$priority", "urgent"}}}}, 1, 0,
+			}}},
+			"today": countSince(today),
+			"week":  countSince(now.Add(-7 * 24 * time.Hour)),
+			"breaches": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{breached, bson.M{"$gte": bson.A{"$created_at", now.Add(-responseWindow)}}}}, 1, 0,
+			}}},
+			"overdue": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{"$open", breached, unanswered}}, 1, 0,
+			}}},
+		}},
+	})
+	if err != nil { return nil, err }
+	var tickets []struct {
+		Open     int64 `bson:"open"`
+		Today    int64 `bson:"today"`
+		Week     int64 `bson:"week"`
+		Urgent   int64 `bson:"urgent"`
+		Breaches int64 `bson:"breaches"`
+		Overdue  int64 `bson:"overdue"`
+	}
+	if err = cur.All(ctx, &tickets); err != nil { return nil, err }
+	if len(tickets) > 0 {
+		st.Open, st.Today, st.Week, st.Urgent = tickets[0].Open, tickets[0].Today, tickets[0].Week, tickets[0].Urgent
+		st.Breaches, st.Overdue = tickets[0].Breaches, tickets[0].Overdue
+	}
+
+	cur, err = MsgCol.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"edit_of": bson.M{"$exists": false}}},
+		bson.M{"$group": bson.M{"_id": "$sender", "n": bson.M{"$sum": 1}}},
+	})
+	if err != nil { return nil, err }
+	var senders []struct {
+		Sender string `bson:"_id"`
+		N      int64  `bson:"n"`
+	}
+	if err = cur.All(ctx, &senders); err != nil { return nil, err }
+	for _, s := range senders { st.Messages[s.Sender] = s.N }
+
+	// Pair each recent ticket with the first staff message sent while it was
+	// open; tickets that recorded first_staff_response_at use that instead
+	cur, err = TicketCol.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"created_at": bson.M{"$gte": now.Add(-responseWindow)}}},
+		bson.M{"$lookup": bson.M{
+			"from": MsgCol.Name(),
+			"let":  bson.M{"uid": "$user_id", "opened": "$created_at", "closed": "$closed_at"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$user_id", "$$uid"}},
+					bson.M{"$eq": bson.A{"$sender", "staff"}},
+					bson.M{"$gte": bson.A{"$timestamp", "$$opened"}},
+					bson.M{"$lte": bson.A{"$timestamp", bson.M{"$ifNull": bson.A{"$$closed", "$$NOW"}}}},
+				}}}},
+				bson.M{"$sort": bson.M{"timestamp": 1}},
+				bson.M{"$limit": 1},
+			},
+			"as": "first",
+		}},
+		bson.M{"$unwind": "$first"},
+		bson.M{"$group": bson.M{
+			"_id": nil,
+			"avg": bson.M{"$avg": bson.M{"$subtract": bson.A{
+				bson.M{"$ifNull": bson.A{"$first_staff_response_at", "$first.timestamp"}}, "$created_at",
+			}}},
+			"n":   bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil { return nil, err }
+	var response []struct {
+		AvgMillis float64 `bson:"avg"`
+		N         int64   `bson:"n"`
+	}
+	if err = cur.All(ctx, &response); err != nil { return nil, err }
+	if len(response) > 0 {
+		st.AvgResponse = time.Duration(response[0].AvgMillis) * time.Millisecond
+		st.Answered = response[0].N
+	}
+	return st, nil
+}
+
+func formatResponse(d time.Duration) string {
+	if d < time.Hour { return plural(int(d.Minutes()), "minute") }
+	return fmt.Sprintf("%.1f hours", d.Hours())
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// customEmoji matches <:name:id> and animated <a:name:id> emoji in message text.
+var customEmoji = regexp.MustCompile(`<(a?):(\w+):(\d+)>`)
+
+// stickerFiles presents a message's stickers as image attachments so they're
+// forwarded like any other picture. Lottie stickers are vector animations
+// Discord only renders natively; stickerNote names them instead.
+func stickerFiles(items []*discordgo.StickerItem) []*discordgo.MessageAttachment {
+	var files []*discordgo.MessageAttachment
+	for _, st := range items {
+		ext, contentType := "png", "image/png"
+		switch st.FormatType {
+		case discordgo.StickerFormatTypeLottie:
+			continue
+		case discordgo.StickerFormatTypeGIF:
+			ext, contentType = "gif", "image/gif"
+		}
+		files = append(files, &discordgo.MessageAttachment{
+			ID: st.ID, Filename: st.Name + "." + ext, ContentType: contentType,
+			URL: discordgo.EndpointCDN + "stickers/" + st.ID + "." + ext,
+		})
+	}
+	return files
+}
+
+// stickerNote is a line naming the message's stickers, or "" without any.
+func stickerNote(items []*discordgo.StickerItem) string {
+	if len(items) == 0 { return "" }
+	names := make([]string, len(items))
+	for i, st := range items {
+		names[i] = "**" + st.Name + "**"
+	}
+	return "🏷️ Sticker: " + strings.Join(names, ", ")
+}
+
+// withStickers adds the sticker note to content and the stickers to files,
+// leaving the message's own slices alone.
+func withStickers(content string, files []*discordgo.MessageAttachment, items []*discordgo.StickerItem) (string, []*discordgo.MessageAttachment) {
+	if len(items) == 0 { return content, files }
+	if content != "" { content += "\n" }
+	all := append(append([]*discordgo.MessageAttachment{}, files...), stickerFiles(items)...)
+	return content + stickerNote(items), all
+}
+
+// portableEmoji rewrites custom emoji the user can't see as links to their
+// images. Emoji from the main guild are left alone, since its members'
+// clients render them; any others would show up as bare :name: text.
+func portableEmoji(s *discordgo.Session, content string) string {
+	return customEmoji.ReplaceAllStringFunc(content, func(tag string) string {
+		parts := customEmoji.FindStringSubmatch(tag)
+		if _, err := s.State.Emoji(MainGuildID, parts[3]); err == nil { return tag }
+		ext := "png"
+		if parts[1] == "a" { ext = "gif" }
+		return "[:" + parts[2] + ":](" + discordgo.EndpointCDN + "emojis/" + parts[3] + "." + ext + ")"
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A sticker-only message has no text of its own; it is forwarded as the
+// sticker's image with a note naming it, never as an empty message.
+func TestStickerOnlyMessage(t *testing.T) {
+	items := []*discordgo.StickerItem{{ID: "999", Name: "wave", FormatType: discordgo.StickerFormatTypePNG}}
+	content, files := withStickers("", nil, items)
+	if content == "" {
+		t.Fatal("sticker-only message has no content")
+	}
+
+	s, rec := testSession(t)
+	author := &discordgo.User{ID: "111", Username: "alice"}
+	if _, err := sendEmbeds(s, "2", userEmbeds(author, content, files)); err != nil { t.Fatal(err) }
+	if len(rec.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(rec.sent))
+	}
+	msg := rec.sent[0]
+	if msg.Content == "" && len(msg.Embeds) == 0 {
+		t.Fatal("sent an empty message")
+	}
+	want := discordgo.EndpointCDN + "stickers/999.png"
+	if e := msg.Embeds[0]; e.Image == nil || e.Image.URL != want {
+		t.Errorf("embed image = %+v, want %s", e.Image, want)
+	}
+	if msg.Embeds[0].Description == "" {
+		t.Error("embed has no sticker note")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// maxTags caps the tags on one ticket.
+const maxTags = 10
+
+// tagName is what a tag may look like: short, lowercase, no spaces.
+var tagName = regexp.MustCompile("^[a-z0-9][a-z0-9_-]{0,23}$")
+
+var errTooManyTags = fmt.Errorf("a ticket can have at most %d tags", maxTags)
+
+// cmdTag is "tag add|remove <name>" and "tag list". Tags are kept on the
+// ticket document and mirrored into the channel topic.
+func cmdTag(c *commandContext) {
+	usage := "Usage: `" + Prefix + "tag add <name>`, `" + Prefix + "tag remove <name>` or `" + Prefix + "tag list`"
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	sub := strings.ToLower(c.args[0])
+	if sub == "list" {
+		t, err := findTicketByChannel(c.m.ChannelID)
+		if err != nil || t == nil {
+			sendText(c.s, c.m.ChannelID, "❌ There's no open ticket in this channel.")
+			return
+		}
+		sendText(c.s, c.m.ChannelID, "🏷️ Tags: "+formatTags(t.Tags))
+		return
+	}
+	if (sub != "add" && sub != "remove") || len(c.args) != 2 {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	tag := strings.ToLower(c.args[1])
+	if !tagName.MatchString(tag) {
+		sendText(c.s, c.m.ChannelID, "❌ Tags are up to 24 letters, digits, `-` or `_`.")
+		return
+	}
+
+	tags, err := updateTags(c.m.ChannelID, tag, sub == "add")
+	if errors.Is(err, errTooManyTags) {
+		sendText(c.s, c.m.ChannelID, "❌ "+err.Error()+".")
+		return
+	}
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot update ticket tags", "channel_id", c.m.ChannelID, "tag", tag, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update the tags.")
+		return
+	}
+	if ch := fetchChannel(c.s, c.m.ChannelID); ch != nil && !ch.IsThread() {
+		if _, err := c.s.ChannelEdit(ch.ID, &discordgo.ChannelEdit{Topic: ticketTopic(c.userID, tags)}); err != nil {
+			slog.Warn("cannot update ticket topic", "channel_id", ch.ID, "err", err)
+		}
+	}
+	sendText(c.s, c.m.ChannelID, "🏷️ Tags: "+formatTags(tags))
+}
+
+// updateTags adds or removes tag on the channel's open ticket and returns the
+// tags it ends up with.
+func updateTags(channelID, tag string, add bool) ([]string, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	filter := bson.M{"channel_id": channelID, "open": true}
+	update := bson.M{"$pull": bson.M{"tags": tag}}
+	if add {
+		// Adding a tag the ticket already has is allowed at the cap
+		filter["$or"] = bson.A{bson.M{"tags": tag}, bson.M{fmt.Sprintf("tags.%d", maxTags-1): bson.M{"$exists": false}}}
+		update = bson.M{"$addToSet": bson.M{"tags": tag}}
+	}
+	var t Ticket
+	err := TicketCol.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) && add {
+		if open, _ := findTicketByChannel(channelID); open != nil { return open.Tags, errTooManyTags }
+	}
+	return t.Tags, err
+}
+
+// ticketTopic is the channel topic for a ticket: the user ID that ticketUser
+// reads back, then any tags on a second line.
+func ticketTopic(userID string, tags []string) string {
+	topic := "Modmail ID: " + userID
+	if len(tags) > 0 { topic += "\nTags: " + strings.Join(tags, ", ") }
+	return topic
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 { return "none" }
+	quoted := make([]string, len(tags))
+	for i, t := range tags {
+		quoted[i] = "`" + t + "`"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// commandContext is what a prefixed staff command gets to work with.
+type commandContext struct {
+	s      *discordgo.Session
+	m      *discordgo.MessageCreate
+	name   string   // the command name, lowercased and without prefix
+	userID string   // the ticket's user
+	args   []string // whitespace-separated arguments after the command name
+	rest   string   // everything after the command name, formatting intact
+}
+
+type textCommand func(c *commandContext)
+
+var textCommands map[string]textCommand
+
+func init() {
+	textCommands = map[string]textCommand{
+		"close":     cmdClose,
+		"reply":     cmdReply,
+		"areply":    cmdReply,
+		"block":     cmdBlock,
+		"unblock":   cmdBlock,
+		"snippet":   cmdSnippet,
+		"logs":      cmdLogs,
+		"alert":     cmdAlert,
+		"config":    cmdConfig,
+		"stats":     cmdStats,
+		"move":      cmdMove,
+		"search":    cmdSearch,
+		"note":      cmdNote,
+		"ping":      cmdPing,
+		"priority":  cmdPriority,
+		"away":      cmdAway,
+		"here":      cmdAway,
+		"export":    cmdExport,
+		"contact":   cmdContact,
+		"rename":    cmdRename,
+		"reload":    cmdReload,
+		"backfill":  cmdBackfill,
+		"history":   cmdHistory,
+		"transfer":  cmdTransfer,
+		"tag":       cmdTag,
+		"tickets":   cmdTickets,
+		"list":      cmdTickets,
+		"merge":     cmdMerge,
+		"broadcast": cmdBroadcast,
+		"remind":    cmdRemind,
+		"filter":    cmdFilter,
+		"usernote":  cmdUserNote,
+		"autoreply": cmdAutoReply,
+		"info":      cmdInfo,
+		"relay":     cmdRelay,
+		"resolve":   cmdResolve,
+	}
+}
+
+// dispatchCommand runs the staff command in m, if there is one. It returns
+// false for anything that isn't a known command so the caller can forward it
+// as a normal reply.
+func dispatchCommand(s *discordgo.Session, m *discordgo.MessageCreate, userID string) bool {
+	if !strings.HasPrefix(m.Content, Prefix) { return false }
+	body := strings.TrimLeft(strings.TrimPrefix(m.Content, Prefix), " \t")
+	name, rest, _ := strings.Cut(body, " ")
+	name = strings.ToLower(name)
+	cmd, ok := textCommands[name]
+	if !ok { return sendSnippet(s, m, userID, name) }
+	if !hasPermission(s, m.GuildID, m.Author.ID, commandLevels[name]) {
+		denyCommand(s, m)
+		return true
+	}
+
+	rest = strings.TrimSpace(rest)
+	cmd(&commandContext{s: s, m: m, name: name, userID: userID, args: strings.Fields(rest), rest: rest})
+	return true
+}
+
+// skipFields drops the first n whitespace-separated fields of s and returns the
+// remainder with its original formatting.
+func skipFields(s string, n int) string {
+	s = strings.TrimSpace(s)
+	for ; n > 0 && s != ""; n-- {
+		i := strings.IndexAny(s, " \t\n")
+		if i < 0 { return "" }
+		s = strings.TrimSpace(s[i:])
+	}
+	return s
+}
+
+// cmdClose asks for confirmation and closes the ticket, or schedules it with
+// "close in <duration> [reason]". "close force" or "close -y" skips the prompt.
+func cmdClose(c *commandContext) {
+	if len(c.args) >= 2 && strings.ToLower(c.args[0]) == "in" {
+		after, err := parseDuration(c.args[1])
+		if err != nil || after <= 0 {
+			sendText(c.s, c.m.ChannelID, "❌ Invalid duration, try something like `2h`, `30m` or `1d`.")
+			return
+		}
+		if err = scheduleClose(c.m.ChannelID, c.m.Author.ID, skipFields(c.rest, 2), after); err != nil {
+			slog.Error("cannot schedule close", "channel_id", c.m.ChannelID, "err", err)
+			sendText(c.s, c.m.ChannelID, "❌ Could not schedule the close.")
+			return
+		}
+		sendText(c.s, c.m.ChannelID, fmt.Sprintf("⏲️ This ticket will close in %s unless someone replies.", after))
+		return
+	}
+	// "force"/"-y" skips the confirmation and "silent"/"-s" the user's DMs, in either order
+	force, silent, flags := false, false, 0
+	for _, arg := range c.args {
+		if a := strings.ToLower(arg); a == "force" || a == "-y" {
+			force = true
+		} else if a == "silent" || a == "-s" {
+			silent = true
+		} else {
+			break
+		}
+		flags++
+	}
+	reason := skipFields(c.rest, flags)
+	if force {
+		closeTicket(c.s, c.m.ChannelID, c.userID, c.m.Author, reason, silent)
+		return
+	}
+	askCloseConfirm(c.s, c.m.ChannelID, c.userID, reason, silent)
+}
+
+func cmdReply(c *commandContext) {
+	author := c.m.Author
+	if c.name == "areply" { author = nil }
+	enqueueForward(c.userID, func() { forwardStaffMessage(c.s, c.m, c.userID, c.rest, author) })
+}
+
+func cmdBlock(c *commandContext) {
+	target := c.userID
+	if len(c.args) > 0 { target = strings.Trim(c.args[0], "<@!>") }
+
+	var err error
+	if c.name == "block" {
+		err = blockUser(target, c.m.Author.ID)
+	} else {
+		err = unblockUser(target)
+	}
+	if err != nil {
+		slog.Error("blocklist update failed", "user_id", target, "command", c.name, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update the blocklist.")
+		return
+	}
+	logAction(c.s, modEvent{Action: c.name, Actor: c.m.Author, UserID: target})
+	react(c.s, c.m.ChannelID, c.m.ID, "✅")
+}
+
+// sendSnippet replies to the user with the named snippet, if it exists.
+func sendSnippet(s *discordgo.Session, m *discordgo.MessageCreate, userID, name string) bool {
+	sn, err := findSnippet(m.GuildID, name)
+	if err != nil {
+		slog.Error("cannot look up snippet", "snippet", name, "err", err)
+	}
+	if sn == nil { return false }
+
+	author := m.Author
+	if AnonReplies { author = nil }
+	text := expandSnippet(sn.Text, userID)
+	enqueueForward(userID, func() { forwardStaffMessage(s, m, userID, text, author) })
+	return true
+}
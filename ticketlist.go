@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const ticketPageSize = 10
+
+// cmdTickets lists open tickets, oldest first; "tickets tag:<name>" keeps only
+// those carrying the tag. Besides ticket channels and the ops channel it works
+// in any staff guild channel, see dispatchOpsCommand.
+func cmdTickets(c *commandContext) {
+	var tag string
+	for _, a := range c.args {
+		if v, ok := strings.CutPrefix(strings.ToLower(a), "tag:"); ok { tag = v }
+	}
+	embed, components, err := ticketsPage(tag, 0)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot list open tickets", "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not list tickets.")
+		return
+	}
+	sendMessage(c.s, c.m.ChannelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed}, Components: components, AllowedMentions: noMentions,
+	})
+}
+
+// handleTicketsPage flips pages; arg is "<page>:<tag>". The list is read
+// again on every press, so it shows tickets as they are now.
+func handleTicketsPage(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	level := commandLevels["tickets"]
+	if len(staffRoles) == 0 && ticketUser(s, i.ChannelID) == "" && i.ChannelID != StaffOpsChannelID { level = permModerator }
+	if i.Member == nil || !hasPermission(s, i.GuildID, i.Member.User.ID, level) {
+		respondEphemeral(s, i, "⛔ You don't have permission to use that command.")
+		return
+	}
+	pageArg, tag, _ := strings.Cut(arg, ":")
+	page, _ := strconv.Atoi(pageArg)
+	embed, components, err := ticketsPage(tag, page)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot list open tickets", "err", err)
+		respondEphemeral(s, i, "❌ Could not list tickets.")
+		return
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Components: components},
+	})
+}
+
+// ticketsPage renders one page of open tickets with their number, user, age
+// and claimer. Channel mentions double as jump links.
+func ticketsPage(tag string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	tickets, err := openTickets()
+	if err != nil { return nil, nil, err }
+	if tag != "" {
+		tickets = slices.DeleteFunc(tickets, func(t Ticket) bool { return !slices.Contains(t.Tags, tag) })
+	}
+	slices.SortFunc(tickets, func(a, b Ticket) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	pages := max(1, (len(tickets)+ticketPageSize-1)/ticketPageSize)
+	page = max(0, min(page, pages-1))
+
+	title := fmt.Sprintf("🎫 %d open tickets", len(tickets))
+	if tag != "" { title += " tagged `" + tag + "`" }
+	var lines []string
+	for _, t := range tickets[page*ticketPageSize : min(len(tickets), (page+1)*ticketPageSize)] {
+		number := "—"
+		if t.Number > 0 { number = fmt.Sprintf("#%d", t.Number) }
+		line := fmt.Sprintf("**%s** <#%s> <@%s> · opened %s", number, t.ChannelID, t.UserID, fmtTime(t.CreatedAt))
+		if t.ClaimedBy != "" { line += " · 🙋 <@" + t.ClaimedBy + ">" }
+		if t.Status == statusResolved { line += " · ✅ resolved" }
+		if len(t.Tags) > 0 { line += " · " + formatTags(t.Tags) }
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 { lines = []string{"No matching tickets."} }
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Description: truncate(strings.Join(lines, "\n"), maxEmbedDesc),
+		Color: 0x95a5a6,
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d", page+1, pages)},
+	}
+	if pages == 1 { return embed, nil, nil }
+
+	components := []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "◀ Previous", Style: discordgo.SecondaryButton, Disabled: page == 0,
+			CustomID: fmt.Sprintf("tickets:%d:%s", page-1, tag)},
+		discordgo.Button{Label: "Next ▶", Style: discordgo.SecondaryButton, Disabled: page >= pages-1,
+			CustomID: fmt.Sprintf("tickets:%d:%s", page+1, tag)},
+	}}}
+	return embed, components, nil
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type Ticket struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	Number    int64         `bson:"ticket_number,omitempty"`
+	UserID    string        `bson:"user_id"`
+	GuildID   string        `bson:"guild_id,omitempty"`
+	ChannelID string        `bson:"channel_id"`
+	Category  string        `bson:"category,omitempty"`
+	Open      bool          `bson:"open"`
+	CreatedAt time.Time     `bson:"created_at"`
+	ClosedAt  time.Time     `bson:"closed_at,omitempty"`
+	ClaimedBy string        `bson:"claimed_by,omitempty"`
+	ClosedBy  string        `bson:"closed_by,omitempty"`
+	// Shown to the user when the ticket is closed
+	CloseReason string `bson:"close_reason,omitempty"`
+	// Closed without telling the user
+	Silent bool `bson:"silent,omitempty"`
+	// Auto-close and "close in" bookkeeping
+	InactivityWarnedAt time.Time `bson:"inactivity_warned_at,omitempty"`
+	CloseAt            time.Time `bson:"close_at,omitempty"`
+	CloseScheduledAt   time.Time `bson:"close_scheduled_at,omitempty"`
+	CloseScheduledBy   string    `bson:"close_scheduled_by,omitempty"`
+	// Set when the user can't receive DMs at all
+	Undeliverable bool `bson:"undeliverable,omitempty"`
+	// low, normal, high or urgent; empty means normal
+	Priority string `bson:"priority,omitempty"`
+	// Staff member who reached out first, for tickets opened with !contact
+	OpenedBy string `bson:"opened_by,omitempty"`
+	// The new-ticket embed, which closes the ticket when staff react 🔒
+	IntroMessageID string `bson:"intro_message_id,omitempty"`
+	// Subject set with !rename, shown in the channel name and transcript
+	Label string `bson:"label,omitempty"`
+	// Response-time tracking against SLA_MINUTES
+	FirstStaffResponseAt time.Time `bson:"first_staff_response_at,omitempty"`
+	SLABreachedAt        time.Time `bson:"sla_breached_at,omitempty"`
+	// Free-form labels from !tag, also listed in the channel topic
+	Tags []string `bson:"tags,omitempty"`
+	// The welcome message in the user's DMs, edited as the ticket changes
+	UserStatusMsgID string `bson:"user_status_msg_id,omitempty"`
+	// Set with !relay off: plain messages in the channel aren't forwarded
+	NoRelay bool `bson:"no_relay,omitempty"`
+	// open, resolved or closed; see cmdResolve
+	Status     string    `bson:"status,omitempty"`
+	ResolvedAt time.Time `bson:"resolved_at,omitempty"`
+	ResolvedBy string    `bson:"resolved_by,omitempty"`
+}
+
+// findOpenTicket returns the user's open ticket, or nil if there isn't one.
+func findOpenTicket(userID string) (*Ticket, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var t Ticket
+	err := TicketCol.FindOne(ctx, bson.M{"user_id": userID, "open": true}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) { return nil, nil }
+	if err != nil { return nil, err }
+	return &t, nil
+}
+
+// lastClosedAt is when the user's most recent ticket was closed, or the zero
+// time if they've never had one closed.
+func lastClosedAt(userID string) (time.Time, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var t Ticket
+	err := TicketCol.FindOne(ctx, bson.M{"user_id": userID, "open": false},
+		options.FindOne().SetSort(bson.D{{Key: "closed_at", Value: -1}})).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) { return time.Time{}, nil }
+	return t.ClosedAt, err
+}
+
+// setTicketFields updates fields on the open ticket in channelID.
+func setTicketFields(channelID string, fields bson.M) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketCol.UpdateOne(ctx, bson.M{"channel_id": channelID, "open": true}, bson.M{"$set": fields})
+	return err
+}
+
+func openTickets() ([]Ticket, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := TicketCol.Find(ctx, bson.M{"open": true})
+	if err != nil { return nil, err }
+	var tickets []Ticket
+	err = cur.All(ctx, &tickets)
+	return tickets, err
+}
+
+func markTicketUndeliverable(channelID string) {
+	if err := setTicketFields(channelID, bson.M{"undeliverable": true}); err != nil {
+		slog.Error("cannot flag ticket undeliverable", "channel_id", channelID, "err", err)
+	}
+}
+
+// findTicketByChannel returns the open ticket living in channelID, or nil.
+func findTicketByChannel(channelID string) (*Ticket, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var t Ticket
+	err := TicketCol.FindOne(ctx, bson.M{"channel_id": channelID, "open": true}).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) { return nil, nil }
+	if err != nil { return nil, err }
+	return &t, nil
+}
+
+// nextTicketNumber atomically increments and returns the ticket counter.
+func nextTicketNumber() (int64, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := CounterCol.FindOneAndUpdate(ctx,
+		bson.M{"_id": "ticket"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
+
+// saveTicket records a newly opened ticket.
+func saveTicket(t Ticket) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	t.Open, t.Status, t.CreatedAt = true, statusOpen, time.Now()
+	_, err := TicketCol.InsertOne(ctx, t)
+	return err
+}
+
+func markTicketClosed(channelID, closedBy, reason string) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketCol.UpdateOne(ctx,
+		bson.M{"channel_id": channelID, "open": true},
+		bson.M{"$set": bson.M{"open": false, "status": statusClosed, "closed_at": time.Now(), "closed_by": closedBy, "close_reason": reason}})
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot mark ticket closed", "channel_id", channelID, "err", err)
+	}
+}
+
+// claimTicket assigns the ticket to staffID unless another staff member already
+// holds it, and returns whoever holds the claim afterwards.
+func claimTicket(channelID, staffID string) (string, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	filter := bson.M{
+		"channel_id": channelID,
+		"open":       true,
+		"claimed_by": bson.M{"$in": bson.A{nil, "", staffID}},
+	}
+	err := TicketCol.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"claimed_by": staffID}}).Err()
+	if err == nil { return staffID, nil }
+	if !errors.Is(err, mongo.ErrNoDocuments) { return "", err }
+
+	t, err := findTicketByChannel(channelID)
+	if err != nil { return "", err }
+	if t == nil { return "", errors.New("no open ticket in this channel") }
+	return t.ClaimedBy, nil
+}
+
+// unclaimTicket releases staffID's claim on the ticket.
+func unclaimTicket(channelID, staffID string) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	res, err := TicketCol.UpdateOne(ctx,
+		bson.M{"channel_id": channelID, "open": true, "claimed_by": staffID},
+		bson.M{"$unset": bson.M{"claimed_by": ""}})
+	if err != nil { return err }
+	if res.MatchedCount == 0 { return errors.New("you haven't claimed this ticket") }
+	return nil
+}
+
+// transferClaim hands the ticket in channelID from whoever holds it, expected
+// to be from ("" for an unclaimed ticket), to staffID. It fails if the claim
+// changed hands in the meantime.
+func transferClaim(channelID, from, staffID string) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	filter := bson.M{"channel_id": channelID, "open": true, "claimed_by": from}
+	if from == "" { filter["claimed_by"] = bson.M{"$exists": false} }
+	res, err := TicketCol.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"claimed_by": staffID}})
+	if err != nil { return err }
+	if res.MatchedCount == 0 { return errors.New("the claim changed while transferring, please try again") }
+	return nil
+}
+
+// ticketClaimer returns who has claimed the ticket in channelID, or "" when
+// claiming is disabled or nobody has.
+func ticketClaimer(channelID string) string {
+	if !features.Claiming { return "" }
+	t, err := findTicketByChannel(channelID)
+	if err != nil || t == nil { return "" }
+	return t.ClaimedBy
+}
+
+// createTicketChannel opens the staff-side home for a new ticket: a private
+// thread under TICKET_CHANNEL_ID with the threads feature, a channel in the target's
+// category otherwise, spilling into an overflow category once it's full.
+// Staff need Manage Threads (or a mention) to see private threads.
+func createTicketChannel(s *discordgo.Session, target Target, name, userID string) (*discordgo.Channel, error) {
+	if !guildAvailable(target.GuildID) { return nil, errGuildGone }
+	if features.Threads {
+		return s.ThreadStartComplex(TicketChannelID, &discordgo.ThreadStart{
+			Name: name, Type: discordgo.ChannelTypeGuildPrivateThread, AutoArchiveDuration: 10080,
+		})
+	}
+	create := func(parentID string) (ch *discordgo.Channel, err error) {
+		err = withRetry(func() error {
+			ch, err = s.GuildChannelCreateComplex(target.GuildID, discordgo.GuildChannelCreateData{
+				Name: name, Type: discordgo.ChannelTypeGuildText, ParentID: parentID, Topic: ticketTopic(userID, nil),
+			}, manualRateLimit)
+			return err
+		}, apiAttempts)
+		return ch, err
+	}
+
+	parentID, err := ticketCategory(s, target, "")
+	if err != nil { return nil, err }
+	ch, err := create(parentID)
+	if isCategoryFull(err) {
+		// Our channel count was stale; Discord knows better
+		slog.Warn("ticket category is full", "category_id", parentID, "err", err)
+		if parentID, err = ticketCategory(s, target, parentID); err != nil { return nil, err }
+		ch, err = create(parentID)
+	}
+	return ch, err
+}
+
+// archiveTicketChannel retires a closed ticket's channel. Threads are archived
+// and locked; channels move to ARCHIVE_CATEGORY_ID, or are deleted without one.
+func archiveTicketChannel(s *discordgo.Session, channelID string) {
+	if ch := fetchChannel(s, channelID); ch != nil && ch.IsThread() {
+		archived, locked := true, true
+		if _, err := s.ChannelEditComplex(channelID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
+			slog.Error("cannot archive thread", "channel_id", channelID, "err", err)
+		}
+		return
+	}
+
+	if ArchiveCategoryID == "" {
+		s.ChannelDelete(channelID)
+	} else if _, err := s.ChannelEditComplex(channelID, &discordgo.ChannelEdit{ParentID: ArchiveCategoryID}); err != nil {
+		slog.Warn("cannot archive channel, deleting instead", "channel_id", channelID, "err", err)
+		s.ChannelDelete(channelID)
+	}
+}
+
+// ticketChannel resolves the user's open ticket channel. The tickets collection
+// is checked first; on a miss we fall back to scanning channel topics (tickets
+// opened before the mapping existed) and backfill the mapping.
+func ticketChannel(s *discordgo.Session, userID string) *discordgo.Channel {
+	t, err := findOpenTicket(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", userID, "err", err)
+	}
+	if t != nil {
+		if ch := fetchChannel(s, t.ChannelID); ch != nil { return ch }
+		// The channel was deleted behind our back
+		markTicketClosed(t.ChannelID, "", "channel deleted")
+	}
+
+	var channels []*discordgo.Channel
+	for _, guildID := range staffGuilds() {
+		channels = append(channels, guildChannels(s, guildID)...)
+	}
+	for _, ch := range channels {
+		// Archived channels keep their topic but are no longer live tickets
+		if ArchiveCategoryID != "" && ch.ParentID == ArchiveCategoryID { continue }
+		if strings.Contains(ch.Topic, userID) {
+			if err := saveTicket(Ticket{UserID: userID, GuildID: ch.GuildID, ChannelID: ch.ID}); err != nil {
+				slog.Error("cannot backfill ticket", "user_id", userID, "channel_id", ch.ID, "err", err)
+			}
+			return ch
+		}
+	}
+	return nil
+}
+
+// ticketHistory returns the user's most recent logged messages, oldest first,
+// along with the total number of messages on record.
+func ticketHistory(userID string, limit int64) ([]ModmailLog, int64, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	total, err := MsgCol.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil || total == 0 { return nil, total, err }
+
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}).SetLimit(limit)
+	cur, err := MsgCol.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil { return nil, total, err }
+
+	var logs []ModmailLog
+	if err = cur.All(ctx, &logs); err != nil { return nil, total, err }
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return logs, total, nil
+}
+
+// postHistorySummary gives staff context when a returning user opens a new
+// ticket.
+func postHistorySummary(s *discordgo.Session, channelID, userID string) {
+	logs, total, err := ticketHistory(userID, 10)
+	if err != nil {
+		slog.Error("cannot load history", "user_id", userID, "err", err)
+		return
+	}
+	if total == 0 { return }
+
+	var lines []string
+	for _, l := range logs {
+		lines = append(lines, fmt.Sprintf("%s **%s**: %s", fmtTime(l.Timestamp), l.Sender, truncate(l.Content, 100)))
+	}
+	_, err = sendEmbed(s, channelID, &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("↩️ Returning user — %d previous messages", total),
+		Description: strings.Join(lines, "\n"),
+		Color: 0x95a5a6,
+	})
+	if err != nil {
+		slog.Error("cannot post history summary", "user_id", userID, "channel_id", channelID, "err", err)
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n { return s }
+	return string(r[:n-1]) + "…"
+}
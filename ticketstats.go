@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// backfillTimeout bounds a full pass over the tickets collection.
+const backfillTimeout = 10 * time.Minute
+
+// TicketStat is the summary written to ticket_stats when a ticket closes, so
+// reports don't have to replay the message log. It shares the ticket's _id,
+// which makes writing it again harmless. Backfilled open tickets have no
+// closed_at until they close and their summary is rewritten.
+type TicketStat struct {
+	TicketID     bson.ObjectID `bson:"_id"`
+	UserID       string        `bson:"user_id"`
+	TicketNumber int64         `bson:"ticket_number,omitempty"`
+	OpenedAt     time.Time     `bson:"opened_at"`
+	ClosedAt     time.Time     `bson:"closed_at,omitempty"`
+	ClosedBy     string        `bson:"closed_by,omitempty"`
+	// Milliseconds until staff first replied; missing if they never did
+	FirstResponseLatency *int64 `bson:"first_response_latency,omitempty"`
+	MessageCount         int64  `bson:"message_count"`
+	Category             string `bson:"category,omitempty"`
+}
+
+// buildTicketStat summarises t, closed at closedAt, from its fields and the
+// user and staff messages logged while it was open.
+func buildTicketStat(t Ticket, closedAt time.Time, closedBy string) (TicketStat, error) {
+	st := TicketStat{
+		TicketID: t.ID, UserID: t.UserID, TicketNumber: t.Number, OpenedAt: t.CreatedAt,
+		ClosedAt: closedAt, ClosedBy: closedBy, Category: t.Category,
+	}
+	ctx, cancel := dbCtx()
+	defer cancel()
+	window := bson.M{"$gte": t.CreatedAt, "$lte": closedAt}
+	n, err := MsgCol.CountDocuments(ctx, bson.M{
+		"user_id": t.UserID, "timestamp": window,
+		"sender": bson.M{"$in": bson.A{"user", "staff"}}, "edit_of": bson.M{"$exists": false},
+	})
+	if err != nil { return st, err }
+	st.MessageCount = n
+
+	first := t.FirstStaffResponseAt
+	if first.IsZero() {
+		// Tickets from before first_staff_response_at was recorded
+		var reply ModmailLog
+		err = MsgCol.FindOne(ctx, bson.M{"user_id": t.UserID, "sender": "staff", "timestamp": window},
+			options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: 1}})).Decode(&reply)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) { return st, err }
+		first = reply.Timestamp
+	}
+	if !first.IsZero() {
+		ms := first.Sub(t.CreatedAt).Milliseconds()
+		st.FirstResponseLatency = &ms
+	}
+	return st, nil
+}
+
+func saveTicketStat(st TicketStat) error {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	_, err := TicketStatsCol.ReplaceOne(ctx, bson.M{"_id": st.TicketID}, st, options.Replace().SetUpsert(true))
+	return err
+}
+
+// recordTicketStat writes the summary for a ticket that has just been closed.
+func recordTicketStat(t Ticket, closedBy string) {
+	if t.ID.IsZero() { return }
+	st, err := buildTicketStat(t, time.Now(), closedBy)
+	if err == nil { err = saveTicketStat(st) }
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot record ticket stats", "user_id", t.UserID, "channel_id", t.ChannelID, "err", err)
+	}
+}
+
+// cmdBackfill writes ticket_stats for closed tickets that don't have one yet,
+// such as those closed before the collection existed, and refreshes the
+// summaries of open tickets so far.
+func cmdBackfill(c *commandContext) {
+	sendText(c.s, c.m.ChannelID, "⏳ Backfilling ticket stats…")
+	go func() {
+		n, err := backfillTicketStats()
+		if err != nil {
+			slog.Error("cannot backfill ticket stats", "written", n, "err", err)
+			sendText(c.s, c.m.ChannelID, fmt.Sprintf("❌ Backfill stopped after %d tickets.", n))
+			return
+		}
+		slog.Info("backfilled ticket stats", "written", n)
+		sendText(c.s, c.m.ChannelID, fmt.Sprintf("✅ Backfilled stats for %d tickets.", n))
+	}()
+}
+
+func backfillTicketStats() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), backfillTimeout)
+	defer cancel()
+	cur, err := TicketCol.Find(ctx, bson.M{})
+	if err != nil { return 0, err }
+	defer cur.Close(ctx)
+
+	n := 0
+	for cur.Next(ctx) {
+		var t Ticket
+		if err := cur.Decode(&t); err != nil { return n, err }
+		if !t.Open {
+			if err := TicketStatsCol.FindOne(ctx, bson.M{"_id": t.ID}).Err(); err == nil {
+				continue
+			} else if !errors.Is(err, mongo.ErrNoDocuments) {
+				return n, err
+			}
+		}
+		// Open tickets, and those closed before closed_at was recorded, count
+		// up to now
+		closedAt := t.ClosedAt
+		if closedAt.IsZero() { closedAt = time.Now() }
+		st, err := buildTicketStat(t, closedAt, t.ClosedBy)
+		if err != nil { return n, err }
+		if t.Open { st.ClosedAt, st.ClosedBy = time.Time{}, "" }
+		if err := saveTicketStat(st); err != nil { return n, err }
+		n++
+	}
+	return n, cur.Err()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+	_ "time/tzdata" // DISPLAY_TZ works without zoneinfo in the container
+)
+
+// displayZone is where displayTime renders times: DISPLAY_TZ, else TZ (which
+// Go already applies to time.Local), else UTC.
+var displayZone = loadDisplayZone()
+
+func loadDisplayZone() *time.Location {
+	name := os.Getenv("DISPLAY_TZ")
+	if name == "" { return time.Local }
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		slog.Warn("ignoring invalid DISPLAY_TZ", "value", name, "err", err)
+		return time.Local
+	}
+	return loc
+}
+
+// fmtTime shows t as a Discord relative timestamp ("3 hours ago"), which every
+// viewer sees in their own timezone, with the exact time on hover. Embed
+// Timestamp fields are localised by Discord already and stay RFC 3339.
+func fmtTime(t time.Time) string {
+	return fmt.Sprintf("<t:%d:R>", t.Unix())
+}
+
+// fmtDate is fmtTime for a calendar date.
+func fmtDate(t time.Time) string {
+	return fmt.Sprintf("<t:%d:D>", t.Unix())
+}
+
+// displayTime is for places Discord doesn't render timestamps, such as embed
+// field names and transcript files.
+func displayTime(t time.Time) string {
+	return t.In(displayZone).Format("2006-01-02 15:04 MST")
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// html/template escapes every field, so user content can't break the markup.
+var transcriptTmpl = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"stamp": func(t time.Time) string { return t.In(displayZone).Format("2006-01-02 15:04:05 MST") },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Modmail transcript {{if .Number}}#{{.Number}}{{else}}{{.UserID}}{{end}}{{if .Label}} — {{.Label}}{{end}}</title>
+<style>
+body { font-family: sans-serif; background: #36393f; color: #dcddde; margin: 2em; }
+header { border-bottom: 1px solid #4f545c; margin-bottom: 1em; padding-bottom: 1em; }
+.msg { margin: 0.5em 0; padding: 0.5em 0.75em; border-left: 4px solid #4f545c; background: #2f3136; }
+.user { border-color: #2ecc71; }
+.staff { border-color: #3498db; }
+.system { border-color: #95a5a6; font-style: italic; }
+.note { border-color: #f1c40f; background: #3a3526; }
+.meta { font-size: 0.8em; color: #72767d; }
+.content { white-space: pre-wrap; margin-top: 0.25em; }
+.was { white-space: pre-wrap; margin-top: 0.25em; font-size: 0.85em; color: #72767d; }
+</style>
+</head>
+<body>
+<header>
+<h1>Modmail transcript{{if .Number}} — ticket #{{.Number}}{{end}}</h1>
+{{if .Label}}<p>Subject: {{.Label}}</p>{{end}}
+<p>User ID: {{.UserID}}</p>
+<p>Opened: {{stamp .Opened}}<br>Closed: {{stamp .Closed}}</p>
+<p>Messages: {{len .Logs}}</p>
+</header>
+{{range .Logs}}<div class="msg {{.Sender}}">
+<div class="meta">{{stamp .Timestamp}} · {{if eq .Sender "note"}}🔒 internal note{{else}}{{.Sender}}{{if .Anonymous}} (anonymous){{end}}{{end}}{{if .HasFile}} · 📎 attachment{{end}}{{if .EditOf}} · ✏️ edited{{end}}</div>
+{{if .Was}}<div class="was">Edited from: {{.Was}}</div>{{end}}
+<div class="content">{{.Content}}</div>
+</div>
+{{end}}</body>
+</html>
+`))
+
+// transcriptEntry is a logged message as the transcript shows it. Was is the
+// wording an edit replaced, when that entry is in the transcript too.
+type transcriptEntry struct {
+	ModmailLog
+	Was string
+}
+
+// generateTranscript renders every logged message for the ticket's user as a
+// standalone HTML page. The user's copy has only their messages and staff
+// replies; notes and system entries, which can name staff, are for staff
+// copies only.
+func generateTranscript(t Ticket, closed time.Time, internal bool) ([]byte, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	// Only this ticket's messages, not the user's earlier tickets
+	filter := bson.M{"user_id": t.UserID, "timestamp": bson.M{"$gte": t.CreatedAt, "$lte": closed}}
+	if !internal { filter["sender"] = bson.M{"$in": bson.A{"user", "staff"}} }
+	cur, err := MsgCol.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil { return nil, err }
+	var logs []ModmailLog
+	if err = cur.All(ctx, &logs); err != nil { return nil, err }
+	entries := make([]transcriptEntry, len(logs))
+	byID := make(map[bson.ObjectID]string, len(logs))
+	for i, l := range logs {
+		entries[i] = transcriptEntry{ModmailLog: l, Was: byID[l.Revises]}
+		byID[l.ID] = l.Content
+	}
+
+	var buf bytes.Buffer
+	err = transcriptTmpl.Execute(&buf, struct {
+		UserID         string
+		Number         int64
+		Label          string
+		Opened, Closed time.Time
+		Logs           []transcriptEntry
+	}{t.UserID, t.Number, t.Label, t.CreatedAt, closed, entries})
+	return buf.Bytes(), err
+}
+
+// transcriptSummary is the metadata posted with a transcript in the log channel.
+func transcriptSummary(t Ticket, closed time.Time) *discordgo.MessageEmbed {
+	closedBy := "the system"
+	if t.ClosedBy != "" { closedBy = "<@" + t.ClosedBy + ">" }
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("🎫 Ticket #%d", t.Number),
+		Color: 0x95a5a6,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: fmt.Sprintf("<@%s> (%s)", t.UserID, t.UserID), Inline: true},
+			{Name: "Opened", Value: fmtDate(t.CreatedAt), Inline: true},
+			{Name: "Closed by", Value: closedBy, Inline: true},
+		},
+		Timestamp: closed.Format(time.RFC3339),
+	}
+	if t.Label != "" { embed.Title += " · " + t.Label }
+	add := func(name, value string) {
+		if value != "" { embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: truncate(value, 1024), Inline: true}) }
+	}
+	add("Category", t.Category)
+	if t.ClaimedBy != "" { add("Claimed by", "<@"+t.ClaimedBy+">") }
+	if len(t.Tags) > 0 { add("Tags", formatTags(t.Tags)) }
+	add("Reason", t.CloseReason)
+	return embed
+}
+
+// startTranscriptThread opens a thread on the transcript message, so any
+// discussion of the ticket stays with it.
+func startTranscriptThread(s *discordgo.Session, t Ticket, msg *discordgo.Message) {
+	who := t.UserID
+	if u, err := s.User(t.UserID); err == nil { who = u.Username }
+	name := fmt.Sprintf("#%04d · %s", t.Number, who)
+	if t.Number == 0 { name = "Ticket · " + who }
+	if _, err := s.MessageThreadStartComplex(msg.ChannelID, msg.ID, &discordgo.ThreadStart{
+		Name: truncate(name, maxChannelName), AutoArchiveDuration: 1440,
+	}); err != nil {
+		slog.Warn("cannot start transcript thread", "user_id", t.UserID, "channel_id", msg.ChannelID, "err", err)
+	}
+}
+
+// postTranscript sends the transcript to LOG_CHANNEL_ID and, unless the ticket
+// was closed silently or user transcripts are off, to the user.
+func postTranscript(s *discordgo.Session, t Ticket) {
+	closed := time.Now()
+	name := fmt.Sprintf("transcript-%s-%s.html", t.UserID, closed.Format("20060102-150405"))
+	if t.Number > 0 { name = fmt.Sprintf("transcript-%04d.html", t.Number) }
+
+	if LogChannelID != "" {
+		data, err := generateTranscript(t, closed, true)
+		if err != nil {
+			slog.Error("cannot generate transcript", "user_id", t.UserID, "err", err)
+			return
+		}
+		msg, err := sendMessage(s, LogChannelID, &discordgo.MessageSend{
+			Content:         fmt.Sprintf("📝 Transcript for <@%s>", t.UserID),
+			Embeds:          []*discordgo.MessageEmbed{transcriptSummary(t, closed)},
+			Files:           []*discordgo.File{{Name: name, ContentType: "text/html", Reader: bytes.NewReader(data)}},
+			AllowedMentions: noMentions,
+		})
+		if err != nil {
+			slog.Error("cannot post transcript", "user_id", t.UserID, "channel_id", LogChannelID, "err", err)
+		} else if features.TranscriptThreads {
+			startTranscriptThread(s, t, msg)
+		}
+	}
+
+	if t.Silent || !features.UserTranscripts { return }
+	sendUserTranscript(s, t, name, closed)
+}
+
+// sendUserTranscript DMs the user their copy of the transcript, which leaves
+// out internal notes. Users known to have DMs closed are skipped.
+func sendUserTranscript(s *discordgo.Session, t Ticket, name string, closed time.Time) {
+	if t.Undeliverable { return }
+	data, err := generateTranscript(t, closed, false)
+	if err != nil {
+		slog.Error("cannot generate transcript", "user_id", t.UserID, "err", err)
+		return
+	}
+	dm, err := openDM(s, t.UserID)
+	if err != nil {
+		slog.Warn("cannot open DM channel", "user_id", t.UserID, "err", err)
+		return
+	}
+	_, err = sendMessage(s, dm.ID, &discordgo.MessageSend{
+		Content:         tr("transcript"),
+		Files:           []*discordgo.File{{Name: name, ContentType: "text/html", Reader: bytes.NewReader(data)}},
+		AllowedMentions: noMentions,
+	})
+	switch {
+	case isCannotDM(err):
+		slog.Info("user has DMs closed, transcript not sent", "user_id", t.UserID)
+	case err != nil:
+		slog.Warn("cannot send transcript to user", "user_id", t.UserID, "err", err)
+	}
+}
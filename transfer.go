@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// cmdTransfer hands a claimed ticket to another staff member. Only the current
+// claimer or a moderator can give a claim away; an unclaimed ticket can be
+// assigned by any staff member.
+func cmdTransfer(c *commandContext) {
+	if !features.Claiming {
+		sendText(c.s, c.m.ChannelID, "❌ Claiming isn't enabled.")
+		return
+	}
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, "Usage: `"+Prefix+"transfer <@staff>`")
+		return
+	}
+	targetID := strings.Trim(c.args[0], "<@!>")
+	member, err := c.s.GuildMember(c.m.GuildID, targetID)
+	if targetID == c.userID || err != nil || member.User.Bot || !hasPermission(c.s, c.m.GuildID, targetID, permStaff) {
+		sendText(c.s, c.m.ChannelID, "❌ Tickets can only be transferred to a staff member.")
+		return
+	}
+
+	from := ticketClaimer(c.m.ChannelID)
+	if from == targetID {
+		sendText(c.s, c.m.ChannelID, "<@"+targetID+"> already has this ticket.")
+		return
+	}
+	if from != "" && from != c.m.Author.ID && !hasPermission(c.s, c.m.GuildID, c.m.Author.ID, permModerator) {
+		sendText(c.s, c.m.ChannelID, "❌ Only <@"+from+"> or a moderator can transfer this ticket.")
+		return
+	}
+	if err := transferClaim(c.m.ChannelID, from, targetID); err != nil {
+		slog.Error("cannot transfer ticket", "channel_id", c.m.ChannelID, "from", from, "to", targetID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ "+err.Error())
+		return
+	}
+
+	previous := "nobody"
+	if from != "" { previous = "<@" + from + ">" }
+	logToDB(c.userID, fmt.Sprintf("Ticket transferred from %s to <@%s> by %s", previous, targetID, c.m.Author.Username), "note", false)
+	slog.Info("ticket transferred", "channel_id", c.m.ChannelID, "from", from, "to", targetID, "by", c.m.Author.ID)
+	logAction(c.s, modEvent{Action: "transfer", Actor: c.m.Author, UserID: c.userID, ChannelID: c.m.ChannelID,
+		Detail: fmt.Sprintf("From %s to <@%s>", previous, targetID)})
+
+	// Ping both sides of the handoff
+	mentions := []string{targetID}
+	if from != "" { mentions = append(mentions, from) }
+	sendMessage(c.s, c.m.ChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("🔁 Ticket transferred from %s to <@%s> by %s.", previous, targetID, c.m.Author.Mention()),
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: mentions},
+	})
+}
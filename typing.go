@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A typing indicator lasts about ten seconds, so there's no point refreshing
+// it more often than this.
+const typingDebounce = 8 * time.Second
+
+var lastTyping = struct {
+	sync.Mutex
+	byChannel map[string]time.Time
+}{byChannel: map[string]time.Time{}}
+
+// sendTyping shows the typing indicator in channelID unless it was triggered
+// recently.
+func sendTyping(s *discordgo.Session, channelID string) {
+	lastTyping.Lock()
+	now := time.Now()
+	if now.Sub(lastTyping.byChannel[channelID]) < typingDebounce {
+		lastTyping.Unlock()
+		return
+	}
+	lastTyping.byChannel[channelID] = now
+	for id, t := range lastTyping.byChannel {
+		if now.Sub(t) >= typingDebounce { delete(lastTyping.byChannel, id) }
+	}
+	lastTyping.Unlock()
+
+	s.ChannelTyping(channelID)
+}
+
+// typingStart mirrors staff typing in a ticket channel into the user's DM.
+func typingStart(s *discordgo.Session, t *discordgo.TypingStart) {
+	if t.GuildID == "" || t.UserID == s.State.User.ID { return }
+	userID := ticketUser(s, t.ChannelID)
+	if userID == "" { return }
+	if dm, err := s.UserChannelCreate(userID); err == nil {
+		sendTyping(s, dm.ID)
+	}
+}
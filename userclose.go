@@ -0,0 +1,66 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// userCloseButton lets the user close their own ticket from the welcome
+// message. A button rather than a keyword, so nothing typed in conversation
+// closes a ticket by accident.
+func userCloseButton() discordgo.MessageComponent {
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: tr("close_button"), Emoji: &discordgo.ComponentEmoji{Name: "🔒"}, Style: discordgo.SecondaryButton, CustomID: "userclose:"},
+	}}
+}
+
+// handleUserClose asks the user to confirm on the first press and closes
+// their open ticket once arg is "yes". The close itself runs under the user's
+// lock, with the ticket looked up again.
+func handleUserClose(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if !features.UserClose || i.User == nil { return }
+	userID := i.User.ID
+	t, err := findOpenTicket(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", userID, "err", err)
+	}
+	if t == nil {
+		respondEphemeral(s, i, tr("no_open_ticket"))
+		return
+	}
+
+	if arg != "yes" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: tr("user_close_confirm"),
+				Flags: discordgo.MessageFlagsEphemeral,
+				Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.Button{Label: tr("user_close_yes"), Style: discordgo.DangerButton, CustomID: "userclose:yes"},
+				}}},
+			},
+		})
+		return
+	}
+
+	// Waiting for the lock can take a while, so acknowledge first. A staff
+	// close or new DM may have got in before it, so look again under it.
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+	unlock := lockUser(userID)
+	defer unlock()
+	status := tr("user_closing")
+	empty := []discordgo.MessageComponent{}
+	if t, err = findOpenTicket(userID); err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "user_id", userID, "err", err)
+	}
+	if t == nil { status = tr("no_open_ticket") }
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &status, Components: &empty})
+	if t == nil { return }
+
+	slog.Info("ticket closed by user", "user_id", userID, "channel_id", t.ChannelID)
+	sendText(s, t.ChannelID, "🔒 The user closed this ticket.")
+	closeTicket(s, t.ChannelID, userID, i.User, "", false)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Accounts younger than this are flagged as possible throwaways.
+const newAccountAge = 7 * 24 * time.Hour
+
+// fetchMember looks the user up in the main guild, returning nil if they're
+// not a member or the lookup isn't possible.
+func fetchMember(s *discordgo.Session, userID string) *discordgo.Member {
+	if member, err := s.State.Member(MainGuildID, userID); err == nil { return member }
+	if !guildAvailable(MainGuildID) { return nil }
+	member, err := s.GuildMember(MainGuildID, userID)
+	if err != nil { return nil }
+	return member
+}
+
+// buildUserInfoEmbed summarises who opened a ticket. member may be nil when
+// the user isn't in the main guild.
+func buildUserInfoEmbed(s *discordgo.Session, user *discordgo.User, member *discordgo.Member) *discordgo.MessageEmbed {
+	created, _ := discordgo.SnowflakeTimestamp(user.ID)
+	age := time.Since(created)
+
+	accountValue := fmt.Sprintf("%s (%s old)", fmtDate(created), humanDuration(age))
+	if age < newAccountAge { accountValue += "\n⚠️ **New account**" }
+
+	embed := &discordgo.MessageEmbed{
+		Title: "👤 " + user.Username,
+		Color: 0x95a5a6,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("256")},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: user.Mention() + " (`" + user.ID + "`)", Inline: true},
+			{Name: "Account created", Value: accountValue, Inline: true},
+		},
+		Footer: &discordgo.MessageEmbedFooter{Text: "User ID: " + user.ID},
+	}
+
+	if member == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Server member", Value: "No"})
+		return embed
+	}
+
+	joined := "Unknown"
+	if !member.JoinedAt.IsZero() { joined = fmtDate(member.JoinedAt) }
+	var roles []string
+	for _, id := range member.Roles {
+		if role, err := s.State.Role(MainGuildID, id); err == nil {
+			roles = append(roles, role.Name)
+		} else {
+			roles = append(roles, id)
+		}
+	}
+	roleValue := "None"
+	if len(roles) > 0 { roleValue = truncate(strings.Join(roles, ", "), 1024) }
+
+	embed.Fields = append(embed.Fields,
+		&discordgo.MessageEmbedField{Name: "Joined server", Value: joined, Inline: true},
+		&discordgo.MessageEmbedField{Name: "Roles", Value: roleValue},
+	)
+	if member.Nick != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Nickname", Value: member.Nick, Inline: true})
+	}
+	return embed
+}
+
+// humanDuration renders d coarsely, e.g. "3 days" or "2 years".
+func humanDuration(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case days >= 365:
+		return plural(days/365, "year")
+	case days >= 30:
+		return plural(days/30, "month")
+	case days >= 1:
+		return plural(days, "day")
+	}
+	return plural(int(d.Hours()), "hour")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 { return "1 " + unit }
+	return fmt.Sprintf("%d %ss", n, unit)
+}
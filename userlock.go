@@ -0,0 +1,36 @@
+package main
+
+import "sync"
+
+// userLock serialises ticket find-or-create per user, so a burst of DMs can't
+// open two channels. Entries are reference counted and dropped when unused.
+type userLock struct {
+	sync.Mutex
+	refs int
+}
+
+var userLocks = struct {
+	sync.Mutex
+	byUser map[string]*userLock
+}{byUser: map[string]*userLock{}}
+
+// lockUser blocks until the caller holds userID's lock and returns the unlock
+// function.
+func lockUser(userID string) func() {
+	userLocks.Lock()
+	l := userLocks.byUser[userID]
+	if l == nil {
+		l = &userLock{}
+		userLocks.byUser[userID] = l
+	}
+	l.refs++
+	userLocks.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		userLocks.Lock()
+		if l.refs--; l.refs == 0 { delete(userLocks.byUser, userID) }
+		userLocks.Unlock()
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UserNote is something staff want to remember about a user across tickets,
+// kept in the user_notes collection and shown whenever they open a ticket.
+type UserNote struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	Text      string        `bson:"text"`
+	AuthorID  string        `bson:"author_id"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// userNotes returns the user's notes, oldest first.
+func userNotes(userID string) ([]UserNote, error) {
+	ctx, cancel := dbCtx()
+	defer cancel()
+	cur, err := UserNoteCol.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil { return nil, err }
+	var notes []UserNote
+	err = cur.All(ctx, &notes)
+	return notes, err
+}
+
+// formatUserNotes lists notes numbered from 1, the numbers "usernote remove"
+// takes.
+func formatUserNotes(notes []UserNote) string {
+	lines := make([]string, len(notes))
+	for i, n := range notes {
+		lines[i] = fmt.Sprintf("`%d.` %s — <@%s>, %s", i+1, n.Text, n.AuthorID, fmtDate(n.CreatedAt))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addUserNotes shows the user's notes on the new-ticket embed.
+func addUserNotes(embed *discordgo.MessageEmbed, userID string) {
+	notes, err := userNotes(userID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot load user notes", "user_id", userID, "err", err)
+		return
+	}
+	if len(notes) == 0 { return }
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "📌 User notes", Value: truncate(formatUserNotes(notes), 1024)})
+}
+
+// cmdUserNote is "usernote add [user] <text>", "usernote remove [user]
+// <number>" and "usernote list [user]". The user defaults to the ticket's.
+func cmdUserNote(c *commandContext) {
+	usage := "Usage: `" + Prefix + "usernote add [user] <text>`, `" + Prefix + "usernote remove [user] <number>` or `" + Prefix + "usernote list [user]`"
+	if len(c.args) == 0 {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	userID, rest := c.userID, skipFields(c.rest, 1)
+	if len(c.args) > 1 {
+		if id := strings.Trim(c.args[1], "<@!>"); isSnowflake(id) { userID, rest = id, skipFields(c.rest, 2) }
+	}
+	if userID == "" {
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+
+	ctx, cancel := dbCtx()
+	defer cancel()
+	var err error
+	switch strings.ToLower(c.args[0]) {
+	case "add":
+		if rest == "" {
+			sendText(c.s, c.m.ChannelID, usage)
+			return
+		}
+		_, err = UserNoteCol.InsertOne(ctx, UserNote{UserID: userID, Text: rest, AuthorID: c.m.Author.ID, CreatedAt: time.Now()})
+		if err == nil {
+			slog.Info("user note added", "user_id", userID, "by", c.m.Author.ID)
+			sendText(c.s, c.m.ChannelID, "📌 Note added for <@"+userID+">.")
+		}
+	case "remove":
+		var notes []UserNote
+		n, convErr := strconv.Atoi(rest)
+		if notes, err = userNotes(userID); err != nil { break }
+		if convErr != nil || n < 1 || n > len(notes) {
+			sendText(c.s, c.m.ChannelID, fmt.Sprintf("❌ Pick a note number from 1 to %d, see `%susernote list`.", len(notes), Prefix))
+			return
+		}
+		if _, err = UserNoteCol.DeleteOne(ctx, bson.M{"_id": notes[n-1].ID}); err == nil {
+			slog.Info("user note removed", "user_id", userID, "by", c.m.Author.ID)
+			sendText(c.s, c.m.ChannelID, "🗑️ Note removed.")
+		}
+	case "list":
+		var notes []UserNote
+		if notes, err = userNotes(userID); err != nil { break }
+		if len(notes) == 0 {
+			sendText(c.s, c.m.ChannelID, "No notes for <@"+userID+">.")
+			return
+		}
+		sendText(c.s, c.m.ChannelID, "📌 Notes for <@"+userID+">:\n"+formatUserNotes(notes))
+	default:
+		sendText(c.s, c.m.ChannelID, usage)
+		return
+	}
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot update user notes", "user_id", userID, "err", err)
+		sendText(c.s, c.m.ChannelID, "❌ Could not update the user's notes.")
+	}
+}
+
+// isSnowflake reports whether s looks like a Discord ID.
+func isSnowflake(s string) bool {
+	if len(s) < 17 || len(s) > 20 { return false }
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Plain message content is capped at 2000 characters, unlike embeds.
+const maxMessageLen = 2000
+
+const webhookName = "Modmail"
+
+var webhooks = struct {
+	sync.Mutex
+	byChannel map[string]*discordgo.Webhook
+}{byChannel: map[string]*discordgo.Webhook{}}
+
+// getOrCreateWebhook returns the bot's webhook for channelID, reusing one the
+// bot created earlier if it exists.
+func getOrCreateWebhook(s *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	webhooks.Lock()
+	defer webhooks.Unlock()
+	if wh, ok := webhooks.byChannel[channelID]; ok { return wh, nil }
+
+	existing, err := s.ChannelWebhooks(channelID)
+	if err != nil { return nil, err }
+	for _, wh := range existing {
+		if wh.Token != "" && wh.User != nil && wh.User.ID == s.State.User.ID {
+			webhooks.byChannel[channelID] = wh
+			return wh, nil
+		}
+	}
+
+	wh, err := s.WebhookCreate(channelID, webhookName, "")
+	if err != nil { return nil, err }
+	webhooks.byChannel[channelID] = wh
+	return wh, nil
+}
+
+// postAs forwards a message into channelID. With webhooks enabled it is posted
+// through the channel webhook under the given name and avatar; otherwise, or
+// if the webhook can't be created, the fallback embeds are sent instead.
+func postAs(s *discordgo.Session, channelID, name, avatar, content string, files []*discordgo.MessageAttachment, fallback []*discordgo.MessageEmbed) (messageLink, error) {
+	link := messageLink{ChannelID: channelID}
+	if features.Webhooks {
+		wh, err := getOrCreateWebhook(s, channelID)
+		if err == nil {
+			link.Webhook = true
+			for _, chunk := range splitLimit(webhookText(content, files), maxMessageLen) {
+				var msg *discordgo.Message
+				err := withRetry(func() (err error) {
+					msg, err = s.WebhookExecute(wh.ID, wh.Token, true, &discordgo.WebhookParams{
+						Content: chunk, Username: name, AvatarURL: avatar,
+						AllowedMentions: noMentions,
+					}, manualRateLimit)
+					return err
+				}, apiAttempts)
+				if err != nil { return link, err }
+				link.MessageIDs = append(link.MessageIDs, msg.ID)
+			}
+			return link, nil
+		}
+		slog.Warn("cannot use webhook, falling back to embeds", "channel_id", channelID, "err", err)
+	}
+
+	sent, err := sendEmbeds(s, channelID, fallback)
+	link.MessageIDs = messageIDs(sent)
+	return link, err
+}
+
+// editWebhookForwarded is editForwarded for messages posted through a webhook.
+func editWebhookForwarded(s *discordgo.Session, link messageLink, name, avatar, content string, files []*discordgo.MessageAttachment) ([]string, error) {
+	wh, err := getOrCreateWebhook(s, link.ChannelID)
+	if err != nil { return nil, err }
+
+	chunks := splitLimit(webhookText(content, files), maxMessageLen)
+	var ids []string
+	for i, chunk := range chunks {
+		var msg *discordgo.Message
+		if i < len(link.MessageIDs) {
+			err := withRetry(func() (err error) {
+				msg, err = s.WebhookMessageEdit(wh.ID, wh.Token, link.MessageIDs[i],
+					&discordgo.WebhookEdit{Content: &chunk, AllowedMentions: noMentions}, manualRateLimit)
+				return err
+			}, apiAttempts)
+			if err == nil {
+				ids = append(ids, msg.ID)
+				continue
+			}
+			if !isUnknownMessage(err) { return ids, err }
+		}
+		err := withRetry(func() (err error) {
+			msg, err = s.WebhookExecute(wh.ID, wh.Token, true, &discordgo.WebhookParams{
+				Content: chunk, Username: name, AvatarURL: avatar,
+				AllowedMentions: noMentions,
+			}, manualRateLimit)
+			return err
+		}, apiAttempts)
+		if err != nil { return ids, err }
+		ids = append(ids, msg.ID)
+	}
+	for i := len(chunks); i < len(link.MessageIDs); i++ {
+		s.WebhookMessageDelete(wh.ID, wh.Token, link.MessageIDs[i])
+	}
+	return ids, nil
+}
+
+// webhookText is the plain-text form of a message: images as bare URLs so
+// Discord previews them, other files as named links.
+func webhookText(content string, files []*discordgo.MessageAttachment) string {
+	lines := []string{massMentions.Replace(content)}
+	for _, a := range files {
+		if reason := validateAttachment(a); reason != "" {
+			lines = append(lines, withheldNote(a, reason))
+		} else if classifyAttachment(a) == kindImage {
+			lines = append(lines, a.URL)
+		} else {
+			lines = append(lines, attachmentLink(a))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sendWelcome greets the user when their ticket opens, unless the welcome
+// message is set to "off". With user close enabled it carries the close
+// button, so turning the welcome off also hides the button. It returns the
+// message, for updateUserStatus to keep current, or nil.
+func sendWelcome(s *discordgo.Session, dmChannelID string, t Ticket) *discordgo.Message {
+	embed := userStatusEmbed(s, t)
+	if embed == nil { return nil }
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, AllowedMentions: noMentions}
+	if features.UserClose { msg.Components = []discordgo.MessageComponent{userCloseButton()} }
+	sent, err := sendMessage(s, dmChannelID, msg)
+	if err != nil {
+		slog.Warn("cannot send welcome message", "user_id", t.UserID, "err", err)
+		return nil
+	}
+	return sent
+}
+
+// userStatusEmbed is the welcome message followed by where the ticket stands:
+// the category it went to and whether staff have picked it up. It is nil when
+// the welcome message is off.
+func userStatusEmbed(s *discordgo.Session, t Ticket) *discordgo.MessageEmbed {
+	st := settings()
+	if strings.EqualFold(st.WelcomeMessage, "off") || st.WelcomeMessage == "" { return nil }
+
+	text := strings.NewReplacer("{user}", "<@"+t.UserID+">", "{guild}", mainGuildName(s)).Replace(st.WelcomeMessage)
+	var status []string
+	if target, ok := targetByName(t.Category); ok && target.Label != "" { status = append(status, tr("category_chosen", target.Label)) }
+	if t.ClaimedBy != "" { status = append(status, tr("status_claimed")) }
+	if len(status) > 0 { text += "\n\n" + strings.Join(status, "\n") }
+
+	opened := t.CreatedAt
+	if opened.IsZero() { opened = time.Now() }
+	return &discordgo.MessageEmbed{
+		Title: st.CreatedTitle,
+		Description: truncate(text, maxEmbedDesc),
+		Color: st.UserColor,
+		Timestamp: opened.Format(time.RFC3339),
+	}
+}
+
+// updateUserStatus edits the welcome message in the user's DMs to match t,
+// rather than sending them another message. Tickets whose welcome wasn't
+// recorded are left alone.
+func updateUserStatus(s *discordgo.Session, t Ticket) {
+	if t.UserStatusMsgID == "" { return }
+	embed := userStatusEmbed(s, t)
+	if embed == nil { return }
+	dm, err := openDM(s, t.UserID)
+	if err != nil {
+		slog.Warn("cannot open DM channel", "user_id", t.UserID, "err", err)
+		return
+	}
+	if _, err := s.ChannelMessageEditEmbed(dm.ID, t.UserStatusMsgID, embed); err != nil {
+		slog.Warn("cannot update user status message", "user_id", t.UserID, "message_id", t.UserStatusMsgID, "err", err)
+	}
+}
+
+// refreshUserStatus is updateUserStatus for the ticket in channelID, after a
+// change to it has been saved.
+func refreshUserStatus(s *discordgo.Session, channelID string) {
+	t, err := findTicketByChannel(channelID)
+	if err != nil {
+		dbErrors.Inc()
+		slog.Error("cannot look up ticket", "channel_id", channelID, "err", err)
+	}
+	if t != nil { updateUserStatus(s, *t) }
+}
+
+// mainGuildName is the community guild's name for messages to users.
+func mainGuildName(s *discordgo.Session) string {
+	if g, err := s.State.Guild(MainGuildID); err == nil { return g.Name }
+	if g, err := s.Guild(MainGuildID); err == nil { return g.Name }
+	return tr("guild_fallback")
+}